@@ -0,0 +1,160 @@
+package goblazer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ConfigBundleFile describes one file of a ConfigBundle.
+type ConfigBundleFile struct {
+	Path   string // path relative to the directory of manifest, in "a/b/c" format
+	Type   string // "tab" for TabFile or "ini" for IniFile
+	Code   string // encoding of file, eg: "utf8", "gbk"
+	SHA256 string // hex encoded sha256 checksum of file content
+}
+
+// ConfigBundle is a set of tab and ini files shipped together. The manifest of bundle is an ini file:
+//
+//	[bundle]
+//	version = 2026.10.01
+//
+//	[npcs.tab]
+//	type = tab
+//	code = gbk
+//	sha256 = 9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08
+//
+// Every section except "bundle" describes a file by its path.
+type ConfigBundle struct {
+	Version string             // version of bundle, used to refuse mismatched client/server data
+	Files   []ConfigBundleFile // files in manifest order
+	tabs    map[string]*TabFile
+	inis    map[string]*IniFile
+}
+
+// LoadConfigBundle reads manifest 'path', verifies the checksum of every listed file and loads them all. It fails
+// on the first missing, modified or unloadable file.
+func LoadConfigBundle(path string) (*ConfigBundle, error) {
+	m := NewIniFile()
+	if !m.Load(path, "utf8") {
+		return nil, fmt.Errorf("bundle manifest %s: load failed", path)
+	}
+
+	b := new(ConfigBundle)
+	b.Version = m.GetString("bundle", "version", "")
+	b.tabs = make(map[string]*TabFile)
+	b.inis = make(map[string]*IniFile)
+	if b.Version == "" {
+		return nil, fmt.Errorf("bundle manifest %s: no version", path)
+	}
+
+	dir := filepath.Dir(path)
+	secms := NewSecNodesMapSorter(m.SecNodes)
+	sort.Sort(secms)
+
+	for _, sec := range secms {
+		name := trimSectionName(sec.Name)
+		if strings.EqualFold(name, "bundle") {
+			continue
+		}
+
+		bf := ConfigBundleFile{
+			Path:   name,
+			Type:   strings.ToLower(m.GetString(name, "type", "")),
+			Code:   m.GetString(name, "code", "utf8"),
+			SHA256: strings.ToLower(m.GetString(name, "sha256", "")),
+		}
+		FormatFilePath(&bf.Path)
+
+		if err := b.loadFile(filepath.Join(dir, bf.Path), &bf); err != nil {
+			return nil, fmt.Errorf("bundle manifest %s: %v", path, err)
+		}
+		b.Files = append(b.Files, bf)
+	}
+
+	return b, nil
+}
+
+// WriteConfigBundleManifest calculates the checksum of each file in 'files' and writes the manifest 'path'. The
+// SHA256 field of 'files' is ignored.
+func WriteConfigBundleManifest(path string, version string, files []ConfigBundleFile) error {
+	m := NewIniFile()
+	m.SetString("bundle", "version", version)
+
+	dir := filepath.Dir(path)
+	for _, bf := range files {
+		buff, err := ioutil.ReadFile(filepath.Join(dir, bf.Path))
+		if err != nil {
+			return err
+		}
+
+		sum := sha256.Sum256(buff)
+		m.SetString(bf.Path, "type", bf.Type)
+		m.SetString(bf.Path, "code", bf.Code)
+		m.SetString(bf.Path, "sha256", hex.EncodeToString(sum[:]))
+	}
+
+	if !m.SaveAtomic(path, "utf8", 0) {
+		return fmt.Errorf("bundle manifest %s: save failed", path)
+	}
+	return nil
+}
+
+// TabFile returns the tab file loaded from 'path', or nil if bundle does not contain it.
+func (b *ConfigBundle) TabFile(path string) *TabFile {
+	FormatFilePath(&path)
+	return b.tabs[path]
+}
+
+// IniFile returns the ini file loaded from 'path', or nil if bundle does not contain it.
+func (b *ConfigBundle) IniFile(path string) *IniFile {
+	FormatFilePath(&path)
+	return b.inis[path]
+}
+
+// CheckVersion returns an error if 'version', eg: the bundle version reported by a client, is not the version of b.
+func (b *ConfigBundle) CheckVersion(version string) error {
+	if version != b.Version {
+		return fmt.Errorf("config bundle version mismatch: expect %s, got %s", b.Version, version)
+	}
+	return nil
+}
+
+func (b *ConfigBundle) loadFile(path string, bf *ConfigBundleFile) error {
+	buff, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	sum := sha256.Sum256(buff)
+	if s := hex.EncodeToString(sum[:]); s != bf.SHA256 {
+		return fmt.Errorf("%s: checksum mismatch, expect %s, got %s", bf.Path, bf.SHA256, s)
+	}
+
+	switch bf.Type {
+	case "tab", "tabfile":
+		if buff, err = decodeFileContent(buff, bf.Code); err != nil {
+			return fmt.Errorf("%s: %v", bf.Path, err)
+		}
+
+		t := NewTabFile()
+		if err = t.loadBytes(buff); err != nil {
+			return fmt.Errorf("%s: %v", bf.Path, err)
+		}
+		b.tabs[bf.Path] = t
+	case "ini", "inifile":
+		f := NewIniFile()
+		if err = f.loadBytes(bf.Path, buff, bf.Code); err != nil {
+			return err
+		}
+		b.inis[bf.Path] = f
+	default:
+		return fmt.Errorf("%s: unknown file type %q", bf.Path, bf.Type)
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package goblazer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_ConfigBundle(t *testing.T) {
+	dir, err := ioutil.TempDir("", "configbundle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ioutil.WriteFile(filepath.Join(dir, "npcs.tab"), []byte("Name\tHP\r\nwolf\t100\r\n"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "server.ini"), []byte("[server]\r\nport=9001\r\n"), 0644)
+
+	manifest := filepath.Join(dir, "bundle.ini")
+	files := []ConfigBundleFile{{Path: "npcs.tab", Type: "tab", Code: "utf8"}, {Path: "server.ini", Type: "ini", Code: "utf8"}}
+	if err = WriteConfigBundleManifest(manifest, "1.0.0", files); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := LoadConfigBundle(manifest)
+	if err != nil || b.CheckVersion("1.0.0") != nil || b.CheckVersion("0.9.0") == nil {
+		t.Error("Test_ConfigBundle failed")
+		return
+	}
+
+	if b.TabFile("npcs.tab").GetIntByStrIdx("wolf", "HP", 0) != 100 || b.IniFile("server.ini").GetInt("server", "port", 0) != 9001 {
+		t.Error("Test_ConfigBundle failed")
+		return
+	}
+
+	files[1].Code = "gb2312x"
+	WriteConfigBundleManifest(manifest, "1.0.0", files)
+	if _, err = LoadConfigBundle(manifest); err == nil || !strings.Contains(err.Error(), "unknown encoding") {
+		t.Errorf("Test_ConfigBundle failed, got %v", err)
+		return
+	}
+
+	files[1].Code = "utf8"
+	WriteConfigBundleManifest(manifest, "1.0.0", files)
+	ioutil.WriteFile(filepath.Join(dir, "npcs.tab"), []byte("Name\tHP\r\nwolf\t999\r\n"), 0644)
+	if _, err = LoadConfigBundle(manifest); err != nil {
+		t.Log("Test_ConfigBundle succeeded")
+	} else {
+		t.Error("Test_ConfigBundle failed")
+	}
+}
@@ -2,9 +2,13 @@ package goblazer
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+
+	"github.com/henrylee2cn/mahonia"
 )
 
 var appRootPath string
@@ -77,3 +81,94 @@ func SetAppRootPath(path string) bool {
 func GetAppRootPath() string {
 	return appRootPath
 }
+
+// WriteFileAtomic writes 'data' to a temporary file in the directory of 'path', flushes it to disk and renames it
+// to 'path', so a crash leaves either the old or the new file but never a truncated one. The file keeps the mode of
+// the old file, or 0644 if it is new. If 'backups' > 0, the old file is copied to "path.bak" with the same mode after
+// the new data is flushed and older backups are rotated to "path.bak.1" ... "path.bak.<backups-1>".
+func WriteFileAtomic(path string, data []byte, backups int) error {
+	var err error
+	var fi *os.File
+
+	mode := os.FileMode(0644)
+	if st, e := os.Stat(path); e == nil {
+		mode = st.Mode().Perm()
+	}
+
+	dir, name := filepath.Split(path)
+	if fi, err = ioutil.TempFile(dir, name+".tmp"); err != nil {
+		return err
+	}
+	tmp := fi.Name()
+
+	if err = fi.Chmod(mode); err == nil {
+		if _, err = fi.Write(data); err == nil {
+			err = fi.Sync()
+		}
+	}
+	if e := fi.Close(); err == nil {
+		err = e
+	}
+	if err == nil && backups > 0 && IsFileExisted(path) { // 新内容落盘后才轮换备份，写失败不影响备份链
+		err = rotateBackupFiles(path, backups, mode)
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	// 同步目录项，保证rename落盘，windows下打开目录会失败，忽略错误
+	if d, e := os.Open(filepath.Dir(path)); e == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
+
+func rotateBackupFiles(path string, backups int, mode os.FileMode) error {
+	bak := func(i int) string {
+		if i == 0 {
+			return path + ".bak"
+		}
+		return path + ".bak." + strconv.Itoa(i)
+	}
+
+	os.Remove(bak(backups - 1))
+	for i := backups - 2; i >= 0; i-- {
+		if IsFileExisted(bak(i)) {
+			if err := os.Rename(bak(i), bak(i+1)); err != nil {
+				return err
+			}
+		}
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(bak(0), data, mode); err != nil {
+		return err
+	}
+	return os.Chmod(bak(0), mode) // 不受umask影响，与原文件权限一致
+}
+
+// decodeFileContent converts file content 'buff' encoded by 'code', eg: "gbk", to utf8.
+func decodeFileContent(buff []byte, code string) ([]byte, error) {
+	if strings.EqualFold(code, "utf8") {
+		return buff, nil
+	}
+
+	mdecoder := mahonia.NewDecoder(strings.ToUpper(code))
+	if mdecoder == nil {
+		return nil, fmt.Errorf("unknown encoding %q", code)
+	}
+
+	s, ok := mdecoder.ConvertStringOK(string(buff))
+	if !ok {
+		return nil, fmt.Errorf("decode %s failed", code)
+	}
+	return []byte(s), nil
+}
@@ -0,0 +1,47 @@
+package goblazer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_WriteFileAtomic(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filewrapper")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "a.ini")
+	for _, s := range []string{"v1", "v2", "v3", "v4"} {
+		if err = WriteFileAtomic(path, []byte(s), 2); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cur, _ := ioutil.ReadFile(path)
+	bak0, _ := ioutil.ReadFile(path + ".bak")
+	bak1, _ := ioutil.ReadFile(path + ".bak.1")
+	files, _ := ioutil.ReadDir(dir)
+
+	if string(cur) != "v4" || string(bak0) != "v3" || string(bak1) != "v2" || len(files) != 3 {
+		t.Error("Test_WriteFileAtomic failed")
+		return
+	}
+
+	other := filepath.Join(dir, "b.ini")
+	WriteFileAtomic(other, []byte("v1"), 0)
+	st0, _ := os.Stat(other)
+	os.Chmod(path, 0600)
+	WriteFileAtomic(path, []byte("v5"), 2)
+	st1, _ := os.Stat(path)
+	st2, _ := os.Stat(path + ".bak")
+
+	if st0.Mode().Perm() == 0644 && st1.Mode().Perm() == 0600 && st2.Mode().Perm() == 0600 {
+		t.Log("Test_WriteFileAtomic succeeded")
+	} else {
+		t.Error("Test_WriteFileAtomic failed")
+	}
+}
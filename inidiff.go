@@ -0,0 +1,280 @@
+package goblazer
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+)
+
+// IniDiffOp is the operation of an IniDiffEntry.
+type IniDiffOp int
+
+const (
+	// IniDiffAdd : section or key is added
+	IniDiffAdd IniDiffOp = iota
+	// IniDiffRemove : section or key is removed
+	IniDiffRemove
+	// IniDiffChange : value of key is changed
+	IniDiffChange
+)
+
+// IniDiffEntry is a difference of a section, if Key is empty, or a key.
+type IniDiffEntry struct {
+	Op      IniDiffOp
+	Section string // section name without brackets
+	Key     string
+	Old     string // value before, empty for IniDiffAdd
+	New     string // value after, empty for IniDiffRemove
+}
+
+// IniDiff is the list of differences between two IniFiles, see Diff.
+type IniDiff []IniDiffEntry
+
+// Diff returns differences from 'a' to 'b' in file order: sections and keys of 'a' first, then sections and keys
+// only in 'b'. Section names are case-insensitive, key names are case-insensitive if 'a' ignores case. Only keys
+// defined in a section are compared, with their values stored in file: ENC(...) values stay encrypted, and
+// interpolation, env overrides and inheritance are not applied.
+func Diff(a, b *IniFile) IniDiff {
+	var d IniDiff
+
+	bsecs := make(map[string]string)
+	for _, sec := range b.Sections() {
+		bsecs[strings.ToLower(sec)] = sec
+	}
+
+	asecs := make(map[string]bool)
+	for _, sec := range a.Sections() {
+		asecs[strings.ToLower(sec)] = true
+
+		bsec, ok := bsecs[strings.ToLower(sec)]
+		if !ok {
+			d = append(d, IniDiffEntry{Op: IniDiffRemove, Section: sec})
+			continue
+		}
+
+		bkeys := make(map[string]string)
+		for _, key := range b.Keys(bsec) {
+			bkeys[a.formatKeyName(key)] = key
+		}
+
+		akeys := make(map[string]bool)
+		for _, key := range a.Keys(sec) {
+			akeys[a.formatKeyName(key)] = true
+
+			oldVal, _ := a.getStoredKeyValue(sec, key)
+			bkey, ok := bkeys[a.formatKeyName(key)]
+			if !ok {
+				d = append(d, IniDiffEntry{Op: IniDiffRemove, Section: sec, Key: key, Old: oldVal})
+				continue
+			}
+
+			if newVal, _ := b.getStoredKeyValue(bsec, bkey); newVal != oldVal {
+				d = append(d, IniDiffEntry{Op: IniDiffChange, Section: sec, Key: key, Old: oldVal, New: newVal})
+			}
+		}
+
+		for _, key := range b.Keys(bsec) {
+			if !akeys[a.formatKeyName(key)] {
+				newVal, _ := b.getStoredKeyValue(bsec, key)
+				d = append(d, IniDiffEntry{Op: IniDiffAdd, Section: sec, Key: key, New: newVal})
+			}
+		}
+	}
+
+	for _, sec := range b.Sections() {
+		if asecs[strings.ToLower(sec)] {
+			continue
+		}
+
+		d = append(d, IniDiffEntry{Op: IniDiffAdd, Section: sec})
+		for _, key := range b.Keys(sec) {
+			newVal, _ := b.getStoredKeyValue(sec, key)
+			d = append(d, IniDiffEntry{Op: IniDiffAdd, Section: sec, Key: key, New: newVal})
+		}
+	}
+
+	return d
+}
+
+// String returns the patch text of d, it can be parsed by ParseIniPatch:
+//
+//	[server]            - section of following keys, "[]" is the global section
+//	-port = 9001        - key removed, or changed if followed by '+' line of the same key
+//	+port = 9002        - key added, or changed
+//	-[old]              - section removed
+//	+[new]              - section added
+//
+// Values are quoted like Save if needed.
+func (d IniDiff) String() string {
+	var buff strings.Builder
+	var sec string
+	var started bool
+
+	for _, e := range d {
+		if e.Key == "" {
+			sign := "+"
+			if e.Op == IniDiffRemove {
+				sign = "-"
+			}
+			fmt.Fprintf(&buff, "%s[%s]\n", sign, e.Section)
+			sec, started = e.Section, true
+			continue
+		}
+
+		if !started || sec != e.Section {
+			fmt.Fprintf(&buff, "[%s]\n", e.Section)
+			sec, started = e.Section, true
+		}
+
+		if e.Op != IniDiffAdd {
+			fmt.Fprintf(&buff, "-%s = %s\n", e.Key, quoteIniValue(e.Old))
+		}
+		if e.Op != IniDiffRemove {
+			fmt.Fprintf(&buff, "+%s = %s\n", e.Key, quoteIniValue(e.New))
+		}
+	}
+
+	return buff.String()
+}
+
+// ParseIniPatch parses a patch text returned by IniDiff.String.
+func ParseIniPatch(s string) (IniDiff, error) {
+	var d IniDiff
+	var sec string
+	var lineNo int
+
+	scanner := bufio.NewScanner(strings.NewReader(s))
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			sec = line[1 : len(line)-1]
+			continue
+		}
+
+		if len(line) < 2 || (line[0] != '+' && line[0] != '-') {
+			return nil, &IniParseError{Line: lineNo, Reason: "invalid patch line"}
+		}
+
+		op := IniDiffAdd
+		if line[0] == '-' {
+			op = IniDiffRemove
+		}
+
+		body := line[1:]
+		if strings.HasPrefix(body, "[") && strings.HasSuffix(body, "]") {
+			sec = body[1 : len(body)-1]
+			d = append(d, IniDiffEntry{Op: op, Section: sec})
+			continue
+		}
+
+		i := strings.IndexByte(body, '=')
+		if i <= 0 {
+			return nil, &IniParseError{Line: lineNo, Reason: "invalid patch line"}
+		}
+
+		key := strings.TrimSpace(body[:i])
+		val := strings.TrimSpace(body[i+1:])
+		if strings.HasPrefix(val, "\"") {
+			var reason string
+			if val, reason = parseQuotedIniValue(val); reason != "" {
+				return nil, &IniParseError{Line: lineNo, Reason: reason}
+			}
+		}
+
+		// '-'行后紧跟同一个key的'+'行表示修改
+		if n := len(d); op == IniDiffAdd && n > 0 && d[n-1].Op == IniDiffRemove && d[n-1].Key == key && d[n-1].Section == sec {
+			d[n-1].Op = IniDiffChange
+			d[n-1].New = val
+			continue
+		}
+
+		if op == IniDiffAdd {
+			d = append(d, IniDiffEntry{Op: op, Section: sec, Key: key, New: val})
+		} else {
+			d = append(d, IniDiffEntry{Op: op, Section: sec, Key: key, Old: val})
+		}
+	}
+
+	return d, scanner.Err()
+}
+
+// ApplyDiff applies 'd' to f. Old values in 'd' must match values stored in f, otherwise nothing is applied and the
+// first conflict is returned. Values are set as they are, so ENC(...) values stay encrypted.
+func (f *IniFile) ApplyDiff(d IniDiff) error {
+	for _, e := range d {
+		if err := f.checkDiffEntry(e); err != nil {
+			return err
+		}
+	}
+
+	for _, e := range d {
+		switch {
+		case e.Key == "" && e.Op == IniDiffRemove:
+			f.RemoveSection(e.Section)
+		case e.Key == "":
+			f.addSecNode(e.Section)
+		case e.Op == IniDiffRemove:
+			f.RemoveKey(e.Section, e.Key)
+		default:
+			f.setKeyValue(e.Section, e.Key, e.New)
+		}
+	}
+	return nil
+}
+
+// ApplyPatch parses patch text 's' and applies it to f, see ApplyDiff.
+func (f *IniFile) ApplyPatch(s string) error {
+	d, err := ParseIniPatch(s)
+	if err != nil {
+		return err
+	}
+	return f.ApplyDiff(d)
+}
+
+func (f *IniFile) checkDiffEntry(e IniDiffEntry) error {
+	conflict := func(format string, a ...interface{}) error {
+		return fmt.Errorf("ini: patch conflict in section [%s] key %s: %s", e.Section, e.Key, fmt.Sprintf(format, a...))
+	}
+
+	if e.Key == "" {
+		if e.Op == IniDiffRemove && !f.IsSectionExisted(e.Section) {
+			return conflict("section does not exist")
+		}
+		return nil
+	}
+
+	val, ok := f.getStoredKeyValue(e.Section, e.Key)
+	switch e.Op {
+	case IniDiffAdd:
+		if ok && val != e.New {
+			return conflict("key already exists with value %q", val)
+		}
+	default:
+		if !ok {
+			return conflict("key does not exist")
+		}
+		if val != e.Old {
+			return conflict("value is %q, expect %q", val, e.Old)
+		}
+	}
+	return nil
+}
+
+// getStoredKeyValue returns the value of key defined in section 'sec' as it is stored.
+func (f *IniFile) getStoredKeyValue(sec string, key string) (string, bool) {
+	secNode, ok := f.SecNodes[f.formatSectionName(&sec)]
+	if !ok {
+		return "", false
+	}
+
+	keyNode, ok := secNode.KeyNodes[f.formatKeyName(key)]
+	if !ok {
+		return "", false
+	}
+	return keyNode.Value, true
+}
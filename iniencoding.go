@@ -0,0 +1,175 @@
+package goblazer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/henrylee2cn/mahonia"
+)
+
+// IniEncodingAuto makes IniFile.Load detect the encoding of file, see DetectEncoding. IniFile.Save with it writes
+// the file in the encoding, BOM and line ending it was loaded with.
+const IniEncodingAuto = "auto"
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// DetectEncoding detects the encoding of 'buff': "utf8", "utf16le" and "utf16be" by BOM, otherwise "utf8" if 'buff'
+// is valid UTF-8 and "gbk" if it is not.
+func DetectEncoding(buff []byte) string {
+	switch {
+	case bytes.HasPrefix(buff, bomUTF8):
+		return "utf8"
+	case bytes.HasPrefix(buff, bomUTF16LE):
+		return "utf16le"
+	case bytes.HasPrefix(buff, bomUTF16BE):
+		return "utf16be"
+	}
+
+	if utf8.Valid(buff) || !isValidGBK(buff) {
+		return "utf8"
+	}
+	return "gbk"
+}
+
+// Encoding returns the encoding of loaded file, "" if nothing is loaded.
+func (f *IniFile) Encoding() string {
+	return f.code
+}
+
+// LineEnding returns the line ending used by Save, it is the line ending of loaded file or "\r\n" by default.
+func (f *IniFile) LineEnding() string {
+	if f.lineEnding == "" {
+		return "\r\n"
+	}
+	return f.lineEnding
+}
+
+// SetLineEnding sets the line ending used by Save, "\r\n" or "\n".
+func (f *IniFile) SetLineEnding(eol string) {
+	f.lineEnding = eol
+}
+
+// encodeContent converts 'str' to encoding 'code'. The encoding and BOM of loaded file are used if 'code' is "" or
+// IniEncodingAuto, a BOM is kept if 'code' is the encoding of loaded file. UTF-16 is always written with a BOM.
+func (f *IniFile) encodeContent(str string, code string) (string, bool) {
+	if code == "" || strings.EqualFold(code, IniEncodingAuto) {
+		if code = f.code; code == "" {
+			code = "utf8"
+		}
+	}
+
+	bom := f.bom && strings.EqualFold(code, f.code)
+	switch strings.ToLower(code) {
+	case "utf8":
+		if bom {
+			str = string(bomUTF8) + str
+		}
+		return str, true
+	case "utf16le", "utf16be":
+		return encodeUTF16(str, strings.EqualFold(code, "utf16be")), true
+	}
+
+	mencoder := mahonia.NewEncoder(strings.ToUpper(code))
+	if mencoder == nil { // 未知编码
+		return "", false
+	}
+	return mencoder.ConvertStringOK(str)
+}
+
+// trimEncodingBOM removes the BOM of encoding 'code' from 'buff'.
+func trimEncodingBOM(buff []byte, code string) ([]byte, bool) {
+	var bom []byte
+
+	switch strings.ToLower(code) {
+	case "utf8":
+		bom = bomUTF8
+	case "utf16le":
+		bom = bomUTF16LE
+	case "utf16be":
+		bom = bomUTF16BE
+	default:
+		return buff, false
+	}
+
+	if bytes.HasPrefix(buff, bom) {
+		return buff[len(bom):], true
+	}
+	return buff, false
+}
+
+// decodeIniContent converts 'buff' in encoding 'code' to UTF-8.
+func decodeIniContent(buff []byte, code string) ([]byte, error) {
+	switch strings.ToLower(code) {
+	case "utf16le":
+		return decodeUTF16(buff, binary.LittleEndian)
+	case "utf16be":
+		return decodeUTF16(buff, binary.BigEndian)
+	}
+	return decodeFileContent(buff, code)
+}
+
+func decodeUTF16(buff []byte, order binary.ByteOrder) ([]byte, error) {
+	if len(buff)%2 != 0 {
+		return nil, errors.New("odd length of UTF-16 content")
+	}
+
+	u := make([]uint16, len(buff)/2)
+	for i := range u {
+		u[i] = order.Uint16(buff[i*2:])
+	}
+	return []byte(string(utf16.Decode(u))), nil
+}
+
+func encodeUTF16(str string, bigEndian bool) string {
+	var buff bytes.Buffer
+	var order binary.ByteOrder = binary.LittleEndian
+
+	if bigEndian {
+		order = binary.BigEndian
+	}
+	b := make([]byte, 2)
+	for _, r := range utf16.Encode([]rune("\uFEFF" + str)) {
+		order.PutUint16(b, r)
+		buff.Write(b)
+	}
+	return buff.String()
+}
+
+// isValidGBK reports whether 'buff' is made up of ASCII and GBK double-byte characters.
+func isValidGBK(buff []byte) bool {
+	for i := 0; i < len(buff); i++ {
+		c := buff[i]
+		if c < 0x80 {
+			continue
+		}
+		if c == 0x80 || c == 0xFF || i+1 >= len(buff) {
+			return false
+		}
+
+		i++
+		if t := buff[i]; t < 0x40 || t == 0x7F || t == 0xFF {
+			return false
+		}
+	}
+	return true
+}
+
+// detectLineEnding returns the line ending of the first line in 'buff', "" if there is only one line.
+func detectLineEnding(buff []byte) string {
+	i := bytes.IndexByte(buff, '\n')
+	if i < 0 {
+		return ""
+	}
+	if i > 0 && buff[i-1] == '\r' {
+		return "\r\n"
+	}
+	return "\n"
+}
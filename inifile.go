@@ -5,32 +5,59 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
-
-	"github.com/henrylee2cn/mahonia"
+	"unicode"
+	"unicode/utf8"
 )
 
 // IniFileKeyNode :
 type IniFileKeyNode struct {
-	ID    uint32 // id of key-value node
-	Name  string // key name of key-value node
-	Value string // key value of key-value node
-	SeqNo uint32 // sequence number
+	Name     string   // key name of key-value node
+	Value    string   // key value of key-value node
+	SeqNo    uint32   // sequence number
+	Comments []string // comment and blank lines before the key, written back by Save
+	File     string   // file the value comes from, empty if the value is set by program
+	Line     int      // line number in File
+	raw      string   // original line in file, written back by Save while Value is unchanged
+	rawValue string   // value parsed from raw
+	included bool     // loaded from an included file, not written by Save
 }
 
-func newIniFileKeyNode(id uint32, name string, value string, no uint32) *IniFileKeyNode {
+func newIniFileKeyNode(name string, value string, no uint32) *IniFileKeyNode {
 	n := new(IniFileKeyNode)
-	n.ID = id
 	n.Name = name
 	n.Value = value
 	n.SeqNo = no
 	return n
 }
 
-// KeyNodesMap :
-type KeyNodesMap map[uint32]*IniFileKeyNode
+// line returns the text of key node in file. Only the value part of original line is replaced if value changed.
+// A multi-line value is written back as a quoted value.
+func (n *IniFileKeyNode) line() string {
+	val := quoteIniValue(n.Value)
+	if n.raw == "" {
+		return fmt.Sprintf("%s%s%s", n.Name, "=", val)
+	}
+
+	if n.Value == n.rawValue { // 值没有改变，保持原样
+		return n.raw
+	}
+
+	first := n.raw
+	if i := strings.IndexByte(first, '\n'); i >= 0 { // 多行的值只保留第一行的格式
+		first = first[:i]
+	}
+
+	i := strings.Index(first, "=") + 1
+	v := first[i:]
+	return first[:i] + v[:len(v)-len(strings.TrimLeft(v, " \t"))] + val
+}
+
+// KeyNodesMap : key nodes of a section, the map key is the key name(lower case if IniFileOptions.IgnoreCase).
+type KeyNodesMap map[string]*IniFileKeyNode
 
 // KeyNodesMapSorter :
 type KeyNodesMapSorter []*IniFileKeyNode
@@ -58,25 +85,40 @@ func (ms KeyNodesMapSorter) Swap(i, j int) {
 	ms[i], ms[j] = ms[j], ms[i]
 }
 
+// hasOwnKey reports whether any key is not loaded from an included file.
+func (ms KeyNodesMapSorter) hasOwnKey() bool {
+	for _, n := range ms {
+		if !n.included {
+			return true
+		}
+	}
+	return false
+}
+
 // IniFileSecNode :
 type IniFileSecNode struct {
-	ID       uint32      // id of section
-	Name     string      // section name
-	SeqNo    uint32      // sequence number
-	KeyNodes KeyNodesMap // all keys of a section
+	Name       string            // section name
+	SeqNo      uint32            // sequence number
+	KeyNodes   KeyNodesMap       // all keys of a section
+	Comments   []string          // comment and blank lines before the section header, written back by Save
+	Parents    []string          // names of parent sections without brackets, see IniFile.SetParents
+	raw        string            // original header line in file
+	rawParents string            // parents in raw, joined by ","
+	shadows    []*IniFileKeyNode // duplicate keys not in KeyNodes, see IniFileOptions.DuplicateKey
+	included   bool              // created by an included file, not written by Save if no key of it is written
 }
 
-func newIniFileSecNode(id uint32, name string, no uint32) *IniFileSecNode {
+func newIniFileSecNode(name string, no uint32) *IniFileSecNode {
 	n := new(IniFileSecNode)
-	n.ID = id
 	n.Name = name
 	n.SeqNo = no
-	n.KeyNodes = make(map[uint32]*IniFileKeyNode)
+	n.KeyNodes = make(KeyNodesMap)
 	return n
 }
 
-// SecNodesMap :
-type SecNodesMap map[uint32]*IniFileSecNode
+// SecNodesMap : section nodes of a file, the map key is the section name with brackets(lower case if
+// IniFileOptions.IgnoreCase), eg: "[server]".
+type SecNodesMap map[string]*IniFileSecNode
 
 // SecNodesMapSorter :
 type SecNodesMapSorter []*IniFileSecNode
@@ -97,6 +139,9 @@ func (ms SecNodesMapSorter) Len() int {
 }
 
 func (ms SecNodesMapSorter) Less(i, j int) bool {
+	if ms[i].Name == IniGlobalSection || ms[j].Name == IniGlobalSection { // 全局section总是在最前面
+		return ms[i].Name == IniGlobalSection && ms[j].Name != IniGlobalSection
+	}
 	return ms[i].SeqNo < ms[j].SeqNo
 }
 
@@ -104,84 +149,121 @@ func (ms SecNodesMapSorter) Swap(i, j int) {
 	ms[i], ms[j] = ms[j], ms[i]
 }
 
+// IniGlobalSection is the name of section which holds keys before the first section header.
+const IniGlobalSection = ""
+
+// IniDupKeyPolicy decides what to do if a key appears more than once in a section of file.
+type IniDupKeyPolicy int
+
+const (
+	// IniDupKeyLastWins : the last value is used.
+	IniDupKeyLastWins IniDupKeyPolicy = iota
+	// IniDupKeyFirstWins : the first value is used.
+	IniDupKeyFirstWins
+	// IniDupKeyError : Load fails.
+	IniDupKeyError
+	// IniDupKeyCollect : all values are kept, GetValues returns them and GetString returns the first one.
+	IniDupKeyCollect
+)
+
+// IniFileOptions :
+type IniFileOptions struct {
+	IgnoreCase       bool              // section and key names are case-insensitive
+	KeyChar          func(r rune) bool // a line starting with a rune accepted by KeyChar is a key-value line, default is IniKeyCharDefault
+	DuplicateKey     IniDupKeyPolicy   // policy of duplicate keys, default is IniDupKeyLastWins
+	Strict           bool              // Load fails on malformed lines instead of keeping them as comments
+	Interpolate      bool              // expand ${ENV_VAR}, ${section.key} and %(key)s in values when read
+	EnvPrefix        string            // if not empty, env var PREFIX_SECTION_KEY overrides [section] key when read
+	IncludeKey       string            // if not empty, key-value line "IncludeKey = path" loads another file at this place
+	LineContinuation bool              // a value ending with '\' is continued by next line
+	Inheritance      bool              // a section header "[sec : p1, p2]" declares parent sections, see IniFile.SetParents
+	SecretKey        []byte            // AES key of 16, 24 or 32 bytes to decrypt ENC(...) values when read, see EncryptIniValue
+}
+
+// IniParseError describes why an ini file can not be loaded. Line is 0 if the error is not about a line.
+type IniParseError struct {
+	File   string // path of file
+	Line   int    // line number starting from 1
+	Reason string // reason of error
+}
+
+func (e *IniParseError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Reason)
+	}
+	return fmt.Sprintf("%s: %s", e.File, e.Reason)
+}
+
+// IniKeyCharDefault accepts letters and digits of any language and "_.-$@" as the first character of key.
+func IniKeyCharDefault(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("_.-$@", r)
+}
+
+// IniKeyCharAlnum only accepts ASCII letters and digits as the first character of key.
+func IniKeyCharAlnum(r rune) bool {
+	return (r >= '0' && r <= '9') || (r >= 'A' && r <= 'Z') || (r >= 'a' && r <= 'z')
+}
+
 // IniFile :
 type IniFile struct {
 	SecNodes     SecNodesMap
+	Comments     []string // comment and blank lines after the last key, written back by Save
+	options      IniFileOptions
+	code         string // encoding of loaded file
+	autoCode     bool   // encoding of loaded file is detected, so is the encoding of each included file
+	bom          bool   // loaded file starts with a BOM
+	lineEnding   string // line ending of loaded file
 	offset       int64
 	seqNoCounter uint32
+	files        []string // all loaded files, including included files
+	includes     []string // stack of files being loaded, to detect include cycles
 }
 
 // NewIniFile :
 func NewIniFile() *IniFile {
+	return NewIniFileWithOptions(IniFileOptions{})
+}
+
+// NewIniFileWithOptions :
+func NewIniFileWithOptions(opts IniFileOptions) *IniFile {
 	f := new(IniFile)
-	f.SecNodes = make(map[uint32]*IniFileSecNode)
+	f.SecNodes = make(SecNodesMap)
+	f.options = opts
 	return f
 }
 
-// Load :
+// Load : 'code' is the encoding of file, IniEncodingAuto or "" to detect it.
 func (f *IniFile) Load(path string, code string) bool {
-	var ok bool
+	return f.LoadWithError(path, code) == nil
+}
+
+// LoadWithError is like Load, but returns an *IniParseError telling the file, line and reason of failure.
+func (f *IniFile) LoadWithError(path string, code string) error {
 	var fi *os.File
 	var err error
 	var buff []byte
-	var size int64
 
 	if fi, err = os.Open(path); err != nil {
-		return false
+		return &IniParseError{File: path, Reason: err.Error()}
 	}
 	defer fi.Close()
 
 	if buff, err = ioutil.ReadAll(fi); err != nil {
-		return false
+		return &IniParseError{File: path, Reason: err.Error()}
 	}
 
-	if ok = strings.EqualFold(code, "utf8"); !ok {
-		var s string
-		mdecoder := mahonia.NewDecoder(strings.ToUpper(code))
-
-		if s, ok = mdecoder.ConvertStringOK(string(buff)); !ok {
-			return false
-		}
-		buff = []byte(s)
-	}
-
-	size = int64(len(buff))
-	f.createLinks(buff, size)
-	return true
+	return f.loadBytes(path, buff, code)
 }
 
 // Save :
 func (f *IniFile) Save(filePath string, code string) bool {
-	var ok, isutf8 bool
+	var ok bool
 	var err error
-	var buff bytes.Buffer
 	var str string
 	var fi *os.File
 
-	secms := NewSecNodesMapSorter(f.SecNodes)
-	sort.Sort(secms)
-
-	for _, sec := range secms {
-		buff.WriteString(fmt.Sprintf("%s\r\n", sec.Name))
-
-		keyms := NewKeyNodesMapSorter(sec.KeyNodes)
-		sort.Sort(keyms)
-
-		for _, key := range keyms {
-			buff.WriteString(fmt.Sprintf("%s%s%s\r\n", key.Name, "=", key.Value))
-		}
-
-		buff.WriteString("\r\n")
-	}
-
-	str = buff.String()
-	isutf8 = strings.EqualFold(code, "utf8")
-	if !isutf8 {
-		code = strings.ToUpper(code)
-		mencoder := mahonia.NewEncoder(code)
-		if str, ok = mencoder.ConvertStringOK(str); !ok {
-			return false
-		}
+	if str, ok = f.encode(code); !ok {
+		return false
 	}
 
 	if fi, err = os.Create(filePath); err != nil {
@@ -196,13 +278,23 @@ func (f *IniFile) Save(filePath string, code string) bool {
 	return true
 }
 
+// SaveAtomic is like Save, but replaces the file atomically and keeps 'backups' old versions, see WriteFileAtomic.
+func (f *IniFile) SaveAtomic(filePath string, code string, backups int) bool {
+	str, ok := f.encode(code)
+	if !ok {
+		return false
+	}
+
+	return WriteFileAtomic(filePath, []byte(str), backups) == nil
+}
+
 // PrintSections :
 func (f *IniFile) PrintSections() {
-	for secID, secNode := range f.SecNodes {
-		fmt.Printf("[Section Node %d] ID = %d, Name = %s\n", secID, secNode.ID, secNode.Name)
+	for _, secNode := range f.SecNodes {
+		fmt.Printf("[Section Node %d] Name = %s\n", secNode.SeqNo, secNode.Name)
 
-		for keyID, keyNode := range f.SecNodes[secID].KeyNodes {
-			fmt.Printf("<Key Node %d> ID = %d, Name = %s, Value = %s\n", keyID, keyNode.ID, keyNode.Name, keyNode.Value)
+		for _, keyNode := range secNode.KeyNodes {
+			fmt.Printf("<Key Node %d> Name = %s, Value = %s, From = %s:%d\n", keyNode.SeqNo, keyNode.Name, keyNode.Value, keyNode.File, keyNode.Line)
 		}
 	}
 }
@@ -214,8 +306,8 @@ func (f *IniFile) Clear() {
 
 // IsSectionExisted :
 func (f *IniFile) IsSectionExisted(sec string) bool {
-	id := f.formatSectionName(&sec)
-	_, ok := f.SecNodes[id]
+	name := f.formatSectionName(&sec)
+	_, ok := f.SecNodes[name]
 	return ok
 }
 
@@ -224,28 +316,29 @@ func (f *IniFile) ClearSection(sec string) {
 	var ok bool
 	var secNode *IniFileSecNode
 
-	id := f.formatSectionName(&sec)
-	if secNode, ok = f.SecNodes[id]; !ok {
+	name := f.formatSectionName(&sec)
+	if secNode, ok = f.SecNodes[name]; !ok {
 		return
 	}
 
-	secNode.KeyNodes = make(map[uint32]*IniFileKeyNode)
+	secNode.KeyNodes = make(KeyNodesMap)
+	secNode.shadows = nil
 }
 
 // RemoveSection :
 func (f *IniFile) RemoveSection(sec string) {
-	id := f.formatSectionName(&sec)
-	if _, ok := f.SecNodes[id]; ok {
-		delete(f.SecNodes, id)
+	name := f.formatSectionName(&sec)
+	if _, ok := f.SecNodes[name]; ok {
+		delete(f.SecNodes, name)
 	}
 }
 
 // ClearKey :
 func (f *IniFile) ClearKey(sec string, key string) {
-	id := f.formatSectionName(&sec)
-	if secNode, ok := f.SecNodes[id]; ok {
-		id = SimpleHashString2ID(key)
-		if keyNode, ok := secNode.KeyNodes[id]; ok {
+	name := f.formatSectionName(&sec)
+	if secNode, ok := f.SecNodes[name]; ok {
+		name = f.formatKeyName(key)
+		if keyNode, ok := secNode.KeyNodes[name]; ok {
 			keyNode.Value = ""
 		}
 	}
@@ -253,12 +346,21 @@ func (f *IniFile) ClearKey(sec string, key string) {
 
 // RemoveKey :
 func (f *IniFile) RemoveKey(sec string, key string) {
-	id := f.formatSectionName(&sec)
-	if secNode, ok := f.SecNodes[id]; ok {
-		id = SimpleHashString2ID(key)
-		if _, ok := secNode.KeyNodes[id]; ok {
-			delete(secNode.KeyNodes, id)
+	name := f.formatSectionName(&sec)
+	if secNode, ok := f.SecNodes[name]; ok {
+		name = f.formatKeyName(key)
+		if _, ok := secNode.KeyNodes[name]; ok {
+			delete(secNode.KeyNodes, name)
 		}
+
+		// 重复的key也要删除，否则重新加载时会生效
+		shadows := secNode.shadows[:0]
+		for _, n := range secNode.shadows {
+			if f.formatKeyName(n.Name) != name {
+				shadows = append(shadows, n)
+			}
+		}
+		secNode.shadows = shadows
 	}
 }
 
@@ -275,6 +377,57 @@ func (f *IniFile) SetString(sec string, key string, val string) bool {
 	return f.setKeyValue(sec, key, val)
 }
 
+// GetValues returns all values of a duplicate key loaded with IniDupKeyCollect policy in file order. For other
+// policies it returns the only value of key.
+func (f *IniFile) GetValues(sec string, key string) []string {
+	var ret []string
+
+	secNode, keyNode := f.findKeyNode(sec, key)
+	if keyNode == nil {
+		return nil
+	}
+
+	ret = append(ret, keyNode.Value)
+	if f.options.DuplicateKey == IniDupKeyCollect {
+		for _, n := range f.sortedShadows(secNode, keyNode.Name) {
+			ret = append(ret, n.Value)
+		}
+	}
+	return ret
+}
+
+// SetValues sets all values of a duplicate key in IniDupKeyCollect policy, each value is saved in a separate line.
+// For other policies only the first value is used.
+func (f *IniFile) SetValues(sec string, key string, vals []string) bool {
+	if len(vals) == 0 {
+		f.RemoveKey(sec, key)
+		return true
+	}
+
+	f.setKeyValue(sec, key, vals[0])
+	if f.options.DuplicateKey != IniDupKeyCollect {
+		return true
+	}
+
+	secNode, keyNode := f.findKeyNode(sec, key)
+	olds := f.sortedShadows(secNode, keyNode.Name)
+	f.RemoveKey(sec, key)
+	secNode.KeyNodes[f.formatKeyName(key)] = keyNode
+
+	for i, v := range vals[1:] {
+		var n *IniFileKeyNode
+		if i < len(olds) {
+			n = olds[i]
+			n.Value = v
+		} else {
+			n = newIniFileKeyNode(keyNode.Name, v, f.seqNoCounter)
+			f.seqNoCounter++
+		}
+		secNode.shadows = append(secNode.shadows, n)
+	}
+	return true
+}
+
 // GetStrings :
 func (f *IniFile) GetStrings(sec string, key string, sep string) []string {
 	var ret []string
@@ -486,10 +639,88 @@ func (f *IniFile) SetBools(sec string, key string, val []bool, sep string) bool
 	return f.setKeyValue(sec, key, s)
 }
 
-func (f *IniFile) createLinks(buff []byte, size int64) {
+func (f *IniFile) loadBytes(path string, buff []byte, code string) error {
+	var err error
+	var bom bool
+
+	auto := code == "" || strings.EqualFold(code, IniEncodingAuto)
+	if auto {
+		code = DetectEncoding(buff)
+	}
+	buff, bom = trimEncodingBOM(buff, code)
+	if buff, err = decodeIniContent(buff, code); err != nil {
+		return &IniParseError{File: path, Reason: err.Error()}
+	}
+
+	if len(f.includes) == 0 { // 只记录主文件的编码
+		f.code = code
+		f.autoCode = auto
+		f.bom = bom
+		f.lineEnding = detectLineEnding(buff)
+	}
+
+	return f.createLinks(path, buff, int64(len(buff)))
+}
+
+func (f *IniFile) encode(code string) (string, bool) {
+	var buff bytes.Buffer
+
+	eol := f.LineEnding()
+	writeLines := func(lines []string) {
+		for _, l := range lines {
+			buff.WriteString(l)
+			buff.WriteString(eol)
+		}
+	}
+
+	secms := NewSecNodesMapSorter(f.SecNodes)
+	sort.Sort(secms)
+
+	for _, sec := range secms {
+		keyms := NewKeyNodesMapSorter(sec.KeyNodes)
+		keyms = append(keyms, sec.shadows...)
+		sort.Sort(keyms)
+
+		if sec.included && !keyms.hasOwnKey() { // 只有被包含文件的key
+			continue
+		}
+
+		writeLines(sec.Comments)
+		if sec.Name == IniGlobalSection {
+			// 全局section没有section头
+		} else if sec.raw != "" && strings.Join(sec.Parents, ",") == sec.rawParents {
+			buff.WriteString(sec.raw + eol)
+		} else {
+			// 新建的section与前面的内容空一行
+			if sec.raw == "" && buff.Len() > 0 && !bytes.HasSuffix(buff.Bytes(), []byte(eol+eol)) {
+				buff.WriteString(eol)
+			}
+			buff.WriteString(sec.header(f.options.Inheritance) + eol)
+		}
+
+		for _, key := range keyms {
+			if key.included {
+				continue
+			}
+
+			writeLines(key.Comments)
+			buff.WriteString(strings.Replace(key.line(), "\n", eol, -1) + eol)
+		}
+	}
+	writeLines(f.Comments)
+
+	return f.encodeContent(buff.String(), code)
+}
+
+func (f *IniFile) createLinks(path string, buff []byte, size int64) error {
 	var ok bool
-	var len, start, end int64
-	var str, sec, key, val string
+	var length, start, end int64
+	var line, str, sec, key, val, reason string
+	var comments, parents []string
+	var lineNo int
+
+	f.pushLoadingFile(path)
+	defer f.popLoadingFile()
 
 	// 清空缓冲偏移
 	f.offset = 0
@@ -497,37 +728,190 @@ func (f *IniFile) createLinks(buff []byte, size int64) {
 	for f.offset < size {
 		start = f.offset
 
-		len = f.readLine(buff, size)
-		if len < 0 { // 文件读完
+		length = f.readLine(buff, size)
+		if length < 0 { // 文件读完
 			break
 		}
 
-		if len == 0 { // 空行
+		lineNo++
+		reason = ""
+		parents = nil
+		end = start + length
+		line = string(buff[start:end])
+		str = strings.TrimSpace(line)
+		if str == "" || str[0] == ';' || str[0] == '#' { // 空行或注释，保留给Save
+			comments = append(comments, line)
 			continue
 		}
 
-		end = start + len
-		str = strings.TrimSpace(string(buff[start:end]))
-		if f.isKeyChar(str[0]) { // key - value
-			if sec == "" { // 没有sec忽略所有
+		if f.isKeyChar(str) { // key - value，第一个section之前的key属于全局section
+			if key, val, ok = f.splitKeyValue(str); ok {
+				first := lineNo
+				lines := []string{line}
+				if val, reason = f.parseValue(buff, size, val, &lines); reason != "" && f.options.Strict {
+					return &IniParseError{File: path, Line: first, Reason: reason}
+				}
+				lineNo += len(lines) - 1
+
+				if f.options.IncludeKey != "" && strings.EqualFold(key, f.options.IncludeKey) {
+					// include行作为重复key原样保存，不参与查找，只用来保持它在文件中的位置
+					incNode := newIniFileKeyNode(key, val, f.seqNoCounter)
+					incNode.Comments = comments
+					incNode.File = path
+					incNode.Line = first
+					incNode.raw = strings.Join(lines, "\n")
+					incNode.rawValue = val
+					incNode.included = f.isIncluding()
+					f.seqNoCounter++
+
+					secNode := f.addSecNode(sec)
+					secNode.shadows = append(secNode.shadows, incNode)
+					comments = nil
+
+					if err := f.loadIncludeFile(path, first, val); err != nil {
+						return err
+					}
+					continue
+				}
+
+				keyNode, err := f.loadKeyNode(sec, key, val)
+				if err != nil {
+					return &IniParseError{File: path, Line: first, Reason: err.Error()}
+				}
+
+				keyNode.Comments = append(keyNode.Comments, comments...)
+				keyNode.File = path
+				keyNode.Line = first
+				keyNode.raw = strings.Join(lines, "\n")
+				keyNode.rawValue = val
+				comments = nil
 				continue
 			}
+			reason = "missing '=' in key-value line"
+		} else if str[0] == '[' { // section处理，section头后面可以有注释
+			sec = str
+			if i := strings.IndexByte(str, ']'); i < 0 {
+				reason = "unterminated section header"
+			} else if rest := strings.TrimSpace(str[i+1:]); rest != "" && rest[0] != ';' && rest[0] != '#' {
+				reason = "unexpected text after section header"
+			} else {
+				sec = str[:i+1]
+				if f.options.Inheritance {
+					sec, parents = splitSectionParents(sec)
+				}
+			}
 
-			if key, val, ok = f.splitKeyValue(str); ok {
-				f.setKeyValue(sec, key, val)
+			if reason == "" || !f.options.Strict {
+				secNode := f.addSecNode(sec)
+				if parents != nil {
+					secNode.Parents = parents
+				}
+
+				if f.isIncluding() { // 被包含文件的section头不需要保存
+					continue
+				}
+
+				if secNode.raw == "" {
+					secNode.Comments = append(secNode.Comments, comments...)
+					secNode.raw = line
+					secNode.rawParents = strings.Join(parents, ",")
+					secNode.included = false
+					comments = nil
+					continue
+				}
+				reason = ""
 			}
-			continue
+		} else {
+			reason = "invalid first character of key"
 		}
 
-		// section处理
-		if str[0] == '[' {
-			sec = str
+		if reason != "" && f.options.Strict {
+			return &IniParseError{File: path, Line: lineNo, Reason: reason}
 		}
+
+		// 无法识别的行或重复的section，原样保留
+		comments = append(comments, line)
+	}
+
+	if !f.isIncluding() { // 被包含文件的注释不需要保存
+		f.Comments = append(f.Comments, comments...)
 	}
+	return nil
+}
+
+func (f *IniFile) pushLoadingFile(path string) {
+	f.files = append(f.files, path)
+	f.includes = append(f.includes, path)
+}
+
+func (f *IniFile) popLoadingFile() {
+	f.includes = f.includes[:len(f.includes)-1]
+}
+
+// isIncluding reports whether an included file is being loaded.
+func (f *IniFile) isIncluding() bool {
+	return len(f.includes) > 1
+}
+
+// loadIncludeFile loads file 'inc' included at line 'lineNo' of 'path'. A relative path is relative to the
+// directory of 'path'.
+func (f *IniFile) loadIncludeFile(path string, lineNo int, inc string) error {
+	if !filepath.IsAbs(inc) {
+		inc = filepath.Join(filepath.Dir(path), inc)
+	}
+
+	for _, p := range f.includes {
+		if filepath.Clean(p) == filepath.Clean(inc) {
+			return &IniParseError{File: path, Line: lineNo, Reason: "include cycle: " + inc}
+		}
+	}
+
+	buff, err := ioutil.ReadFile(inc)
+	if err != nil {
+		return &IniParseError{File: path, Line: lineNo, Reason: err.Error()}
+	}
+
+	offset := f.offset
+	defer func() { f.offset = offset }()
+
+	code := f.code
+	if f.autoCode { // 主文件的编码是检测出来的，被包含文件也各自检测
+		code = IniEncodingAuto
+	}
+	return f.loadBytes(inc, buff, code)
+}
+
+// loadKeyNode adds a key loaded from file according to IniFileOptions.DuplicateKey.
+func (f *IniFile) loadKeyNode(sec string, key string, val string) (*IniFileKeyNode, error) {
+	secNode := f.addSecNode(sec)
+	name := f.formatKeyName(key)
+
+	old, ok := secNode.KeyNodes[name]
+	if !ok {
+		return f.addKeyNode(secNode, key, val), nil
+	}
+
+	keyNode := newIniFileKeyNode(key, val, f.seqNoCounter)
+	keyNode.included = f.isIncluding()
+	f.seqNoCounter++
+
+	switch f.options.DuplicateKey {
+	case IniDupKeyError:
+		return nil, fmt.Errorf("duplicate key %s in section %s", key, secNode.Name)
+	case IniDupKeyLastWins:
+		secNode.KeyNodes[name] = keyNode
+		secNode.shadows = append(secNode.shadows, old)
+	default: // IniDupKeyFirstWins, IniDupKeyCollect
+		secNode.shadows = append(secNode.shadows, keyNode)
+	}
+
+	return keyNode, nil
 }
 
 func (f *IniFile) removeLinks() {
-	f.SecNodes = make(map[uint32]*IniFileSecNode)
+	f.SecNodes = make(SecNodesMap)
+	f.Comments = nil
+	f.files = nil
 	f.seqNoCounter = 0
 }
 
@@ -554,20 +938,12 @@ func (f *IniFile) readLine(buff []byte, size int64) int64 {
 	return len
 }
 
-func (f *IniFile) isKeyChar(ch byte) bool {
-	if ch >= '0' && ch <= '9' {
-		return true
-	}
-
-	if ch >= 'A' && ch <= 'Z' {
-		return true
-	}
-
-	if ch >= 'a' && ch <= 'z' {
-		return true
+func (f *IniFile) isKeyChar(s string) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	if f.options.KeyChar != nil {
+		return f.options.KeyChar(r)
 	}
-
-	return false
+	return IniKeyCharDefault(r)
 }
 
 func (f *IniFile) splitKeyValue(s string) (string, string, bool) {
@@ -583,38 +959,67 @@ func (f *IniFile) splitKeyValue(s string) (string, string, bool) {
 }
 
 func (f *IniFile) setKeyValue(sec string, key string, val string) bool {
-	var id uint32
-	var ok bool
-	var secNode *IniFileSecNode
-	var keyNode *IniFileKeyNode
+	return f.setKeyValueFrom(sec, key, val, "", 0)
+}
+
+// setKeyValueFrom sets value with its provenance.
+func (f *IniFile) setKeyValueFrom(sec string, key string, val string, file string, line int) bool {
+	secNode := f.addSecNode(sec)
+	keyNode := f.addKeyNode(secNode, key, val)
+	if keyNode.included {
+		// 被包含文件的key改为本文件所有，排到include行之后，否则重新加载时会被包含文件覆盖
+		keyNode.SeqNo = f.seqNoCounter
+		keyNode.Comments = nil
+		keyNode.raw = ""
+		keyNode.rawValue = ""
+		f.seqNoCounter++
+	}
+
+	keyNode.File = file
+	keyNode.Line = line
+	keyNode.included = false
+	secNode.included = false
+	return true
+}
 
+func (f *IniFile) addSecNode(sec string) *IniFileSecNode {
 	// 查找对应的Section Node
-	id = f.formatSectionName(&sec)
-	if secNode, ok = f.SecNodes[id]; !ok {
+	name := f.formatSectionName(&sec)
+	secNode, ok := f.SecNodes[name]
+	if !ok {
 		// 如果Section Node不存在，创建一个
-		secNode = newIniFileSecNode(id, sec, f.seqNoCounter)
-		f.SecNodes[id] = secNode
+		secNode = newIniFileSecNode(sec, f.seqNoCounter)
+		secNode.included = f.isIncluding()
+		f.SecNodes[name] = secNode
 		f.seqNoCounter++
-	} else {
-		// 如果Section Node存在
 	}
 
+	return secNode
+}
+
+func (f *IniFile) addKeyNode(secNode *IniFileSecNode, key string, val string) *IniFileKeyNode {
 	// 查找对应的Key Node
-	id = SimpleHashString2ID(key)
-	if keyNode, ok = secNode.KeyNodes[id]; !ok {
+	name := f.formatKeyName(key)
+	keyNode, ok := secNode.KeyNodes[name]
+	if !ok {
 		// 如果Key Node不存在
-		keyNode = newIniFileKeyNode(id, key, val, f.seqNoCounter)
-		secNode.KeyNodes[id] = keyNode
+		keyNode = newIniFileKeyNode(key, val, f.seqNoCounter)
+		keyNode.included = f.isIncluding()
+		secNode.KeyNodes[name] = keyNode
 		f.seqNoCounter++
 	} else {
 		// 如果Key Node存在，覆盖旧值
-		secNode.KeyNodes[id].Value = val
+		keyNode.Value = val
 	}
 
-	return true
+	return keyNode
 }
 
-func (f *IniFile) formatSectionName(sec *string) uint32 {
+func (f *IniFile) formatSectionName(sec *string) string {
+	if *sec == IniGlobalSection {
+		return IniGlobalSection
+	}
+
 	if (*sec)[0] != '[' {
 		*sec = JoinStrings([]string{"[", *sec})
 	}
@@ -624,17 +1029,54 @@ func (f *IniFile) formatSectionName(sec *string) uint32 {
 		*sec = JoinStrings([]string{*sec, "]"})
 	}
 
-	return SimpleHashString2ID(*sec)
+	return f.formatKeyName(*sec)
 }
 
-func (f *IniFile) getKeyValue(sec string, key string) (string, bool) {
-	id := f.formatSectionName(&sec)
-	if secNode, ok := f.SecNodes[id]; ok {
-		id := SimpleHashString2ID(key)
-		if keyNode, ok := secNode.KeyNodes[id]; ok {
-			return keyNode.Value, true
+// trimSectionName returns section name 'sec' without brackets, eg: "server" for "[server]".
+func trimSectionName(sec string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(sec, "["), "]")
+}
+
+func (f *IniFile) formatKeyName(key string) string {
+	if f.options.IgnoreCase {
+		return strings.ToLower(key)
+	}
+	return key
+}
+
+// findKeyNode finds key in section 'sec' or its parent sections.
+func (f *IniFile) findKeyNode(sec string, key string) (*IniFileSecNode, *IniFileKeyNode) {
+	return f.findInheritedKeyNode(sec, f.formatKeyName(key), nil)
+}
+
+// sortedShadows returns duplicate nodes of key 'key' in file order.
+func (f *IniFile) sortedShadows(secNode *IniFileSecNode, key string) KeyNodesMapSorter {
+	var ret KeyNodesMapSorter
+
+	name := f.formatKeyName(key)
+	for _, n := range secNode.shadows {
+		if f.formatKeyName(n.Name) == name {
+			ret = append(ret, n)
 		}
 	}
 
+	sort.Sort(ret)
+	return ret
+}
+
+func (f *IniFile) getKeyValue(sec string, key string) (string, bool) {
+	return f.resolveKeyValue(sec, key, nil)
+}
+
+// getRawKeyValue returns the value of key without interpolation and decryption.
+func (f *IniFile) getRawKeyValue(sec string, key string) (string, bool) {
+	if s, ok := f.getEnvKeyValue(sec, key); ok {
+		return s, true
+	}
+
+	if _, keyNode := f.findKeyNode(sec, key); keyNode != nil {
+		return keyNode.Value, true
+	}
+
 	return "", false
 }
@@ -0,0 +1,852 @@
+package goblazer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestIniFile(t *testing.T, content string) *IniFile {
+	return newTestIniFileWithOptions(t, content, IniFileOptions{})
+}
+
+func newTestIniFileWithOptions(t *testing.T, content string, opts IniFileOptions) *IniFile {
+	fi, err := ioutil.TempFile("", "inifile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(fi.Name())
+
+	fi.WriteString(content)
+	fi.Close()
+
+	f := NewIniFileWithOptions(opts)
+	if !f.Load(fi.Name(), "utf8") {
+		t.Fatal("load ini file failed")
+	}
+	return f
+}
+
+func saveTestIniFile(t *testing.T, f *IniFile) string {
+	fi, err := ioutil.TempFile("", "inifile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fi.Close()
+	defer os.Remove(fi.Name())
+
+	if !f.Save(fi.Name(), "utf8") {
+		t.Fatal("save ini file failed")
+	}
+
+	buff, _ := ioutil.ReadFile(fi.Name())
+	return string(buff)
+}
+
+func Test_IniFileRoundTrip(t *testing.T) {
+	content := "; server config\r\n\r\n[server]\r\n# listen port\r\nport = 9001\r\nhost=  0.0.0.0\r\n\r\n[db]\r\nuser = root\r\n; end\r\n"
+	f := newTestIniFile(t, content)
+
+	if s := saveTestIniFile(t, f); s != content {
+		t.Errorf("Test_IniFileRoundTrip failed, got %q", s)
+		return
+	}
+
+	f.SetInt("server", "port", 9002)
+	f.SetString("db", "password", "123")
+	expect := "; server config\r\n\r\n[server]\r\n# listen port\r\nport = 9002\r\nhost=  0.0.0.0\r\n\r\n[db]\r\nuser = root\r\npassword=123\r\n; end\r\n"
+	if s := saveTestIniFile(t, f); s == expect {
+		t.Log("Test_IniFileRoundTrip succeeded")
+	} else {
+		t.Errorf("Test_IniFileRoundTrip failed, got %q", s)
+	}
+}
+
+func Test_IniFileHashCollision(t *testing.T) {
+	// "aaaa0" and "aaaft" have the same SimpleHashString2ID
+	if SimpleHashString2ID("aaaa0") != SimpleHashString2ID("aaaft") {
+		t.Fatal("Test_IniFileHashCollision: names do not collide")
+	}
+
+	f := newTestIniFile(t, "[aaaa0]\r\naaaa0=1\r\naaaft=2\r\n[aaaft]\r\naaaa0=3\r\n")
+	if f.GetInt("aaaa0", "aaaa0", 0) == 1 && f.GetInt("aaaa0", "aaaft", 0) == 2 && f.GetInt("aaaft", "aaaa0", 0) == 3 {
+		t.Log("Test_IniFileHashCollision succeeded")
+	} else {
+		t.Error("Test_IniFileHashCollision failed")
+	}
+}
+
+func Test_IniFileIgnoreCase(t *testing.T) {
+	f := NewIniFileWithOptions(IniFileOptions{IgnoreCase: true})
+	f.SetString("Server", "Port", "9001")
+
+	g := NewIniFile()
+	g.SetString("Server", "Port", "9001")
+
+	if f.GetString("SERVER", "port", "") == "9001" && g.GetString("SERVER", "port", "") == "" {
+		t.Log("Test_IniFileIgnoreCase succeeded")
+	} else {
+		t.Error("Test_IniFileIgnoreCase failed")
+	}
+}
+
+func Test_IniFileGlobalKeys(t *testing.T) {
+	content := "_debug = 1\r\n.net.port=80\r\n名字=服务器\r\n[server]\r\nport=9001\r\n"
+	f := newTestIniFile(t, content)
+
+	if f.GetBool(IniGlobalSection, "_debug", false) && f.GetInt("", ".net.port", 0) == 80 && f.GetString("", "名字", "") == "服务器" {
+		t.Log("Test_IniFileGlobalKeys succeeded")
+	} else {
+		t.Error("Test_IniFileGlobalKeys failed")
+	}
+
+	if s := saveTestIniFile(t, f); s != content {
+		t.Errorf("Test_IniFileGlobalKeys failed, got %q", s)
+	}
+}
+
+func Test_IniFileDuplicateKey(t *testing.T) {
+	content := "[server]\r\nhost=a\r\nport=1\r\nhost=b\r\n"
+
+	f := newTestIniFile(t, content)
+	f.SetString("server", "host", "c")
+	g := newTestIniFile(t, saveTestIniFile(t, f))
+
+	if f.GetString("server", "host", "") != "c" || g.GetString("server", "host", "") != "c" {
+		t.Error("Test_IniFileDuplicateKey failed")
+		return
+	}
+
+	f = NewIniFileWithOptions(IniFileOptions{DuplicateKey: IniDupKeyCollect})
+	f.loadBytes("", []byte(content), "utf8")
+	if vals := f.GetValues("server", "host"); len(vals) != 2 || vals[0] != "a" || vals[1] != "b" {
+		t.Error("Test_IniFileDuplicateKey failed")
+		return
+	}
+
+	f.SetValues("server", "host", []string{"x", "y", "z"})
+	if s := saveTestIniFile(t, f); s != "[server]\r\nhost=x\r\nport=1\r\nhost=y\r\nhost=z\r\n" {
+		t.Errorf("Test_IniFileDuplicateKey failed, got %q", s)
+		return
+	}
+
+	f = NewIniFileWithOptions(IniFileOptions{DuplicateKey: IniDupKeyError})
+	if f.loadBytes("", []byte(content), "utf8") != nil {
+		t.Log("Test_IniFileDuplicateKey succeeded")
+	} else {
+		t.Error("Test_IniFileDuplicateKey failed")
+	}
+}
+
+func Test_IniFileStrict(t *testing.T) {
+	content := "[server]\r\nport=9001\r\n\r\nhost\r\n[db\r\n"
+
+	f := NewIniFile()
+	if err := f.loadBytes("a.ini", []byte(content), "utf8"); err != nil || f.GetInt("server", "port", 0) != 9001 {
+		t.Error("Test_IniFileStrict failed")
+		return
+	}
+
+	f = NewIniFileWithOptions(IniFileOptions{Strict: true})
+	err := f.loadBytes("a.ini", []byte(content), "utf8")
+	if e, ok := err.(*IniParseError); ok && e.Line == 4 && err.Error() == "a.ini:4: missing '=' in key-value line" {
+		t.Log("Test_IniFileStrict succeeded")
+	} else {
+		t.Errorf("Test_IniFileStrict failed, got %v", err)
+	}
+}
+
+type testIniDBConfig struct {
+	User     string `ini:"user" default:"root"`
+	Password string `ini:"password"`
+}
+
+type testIniServerConfig struct {
+	Port    uint16          `ini:"port"`
+	Rate    float64         `ini:"rate" default:"0.5"`
+	Debug   bool            `ini:"debug"`
+	Zones   []int32         `ini:"zones" sep:";"`
+	Ignored string          `ini:"-"`
+	DB      testIniDBConfig `ini:"db"`
+}
+
+func Test_IniFileMapTo(t *testing.T) {
+	f := newTestIniFile(t, "[server]\r\nport=9001\r\ndebug=yes\r\nzones=1;2;3\r\n[server.db]\r\npassword=123\r\n")
+
+	var c testIniServerConfig
+	if err := f.MapTo("server", &c); err != nil {
+		t.Fatal(err)
+	}
+
+	if c.Port != 9001 || c.Rate != 0.5 || !c.Debug || len(c.Zones) != 3 || c.Zones[2] != 3 || c.DB.User != "root" || c.DB.Password != "123" {
+		t.Errorf("Test_IniFileMapTo failed, got %+v", c)
+		return
+	}
+
+	g := NewIniFile()
+	g.ReflectFrom("server", c)
+	if g.GetString("server", "zones", "") == "1;2;3" && g.GetString("server.db", "user", "") == "root" && g.GetFloat64("server", "rate", 0) == 0.5 {
+		t.Log("Test_IniFileMapTo succeeded")
+	} else {
+		t.Error("Test_IniFileMapTo failed")
+	}
+}
+
+func Test_IniFileInterpolate(t *testing.T) {
+	os.Setenv("GOBLAZER_TEST_HOME", "/home/game")
+	os.Setenv("GOBLAZER_SERVER_PORT", "9100")
+	defer os.Unsetenv("GOBLAZER_TEST_HOME")
+	defer os.Unsetenv("GOBLAZER_SERVER_PORT")
+
+	content := "root=${GOBLAZER_TEST_HOME}\r\n[server]\r\nport=9001\r\nlog=%(root)s/log/%(name)s.log\r\nname=gs${server.port}\r\n[loop]\r\na=${loop.b}\r\nb=${loop.a}\r\n"
+	f := NewIniFileWithOptions(IniFileOptions{Interpolate: true, EnvPrefix: "GOBLAZER"})
+	if err := f.loadBytes("", []byte(content), "utf8"); err != nil {
+		t.Fatal(err)
+	}
+
+	if f.GetInt("server", "port", 0) != 9100 || f.GetString("server", "log", "") != "/home/game/log/gs9100.log" {
+		t.Errorf("Test_IniFileInterpolate failed, got %s", f.GetString("server", "log", ""))
+		return
+	}
+
+	if f.GetString("loop", "a", "") == "${loop.a}" {
+		t.Log("Test_IniFileInterpolate succeeded")
+	} else {
+		t.Errorf("Test_IniFileInterpolate failed, got %s", f.GetString("loop", "a", ""))
+	}
+}
+
+func Test_IniFileInclude(t *testing.T) {
+	dir, err := ioutil.TempDir("", "inifile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ioutil.WriteFile(dir+"/common.ini", []byte("[server]\r\nport=9001\r\nhost=0.0.0.0\r\n[log]\r\nlevel=info\r\n"), 0644)
+	ioutil.WriteFile(dir+"/gs.ini", []byte("include = common.ini\r\n[server]\r\nport=9002\r\n"), 0644)
+	ioutil.WriteFile(dir+"/loop.ini", []byte("include = loop.ini\r\n"), 0644)
+
+	f := NewIniFileWithOptions(IniFileOptions{IncludeKey: "include"})
+	if err = f.LoadWithError(dir+"/gs.ini", "utf8"); err != nil {
+		t.Fatal(err)
+	}
+
+	src, _ := f.Provenance("server", "host")
+	if f.GetInt("server", "port", 0) != 9002 || f.GetString("log", "level", "") != "info" || src != dir+"/common.ini:3" {
+		t.Error("Test_IniFileInclude failed")
+		return
+	}
+
+	if s := saveTestIniFile(t, f); s != "include = common.ini\r\n[server]\r\nport=9002\r\n" {
+		t.Errorf("Test_IniFileInclude failed, got %q", s)
+		return
+	}
+
+	ioutil.WriteFile(dir+"/gm.ini", []byte("[server]\r\nport=2\r\ninclude=common.ini\r\n"), 0644)
+	h := NewIniFileWithOptions(IniFileOptions{IncludeKey: "include"})
+	h.LoadWithError(dir+"/gm.ini", "utf8")
+	h.SetString("server", "host", "9")
+	h.Save(dir+"/gm.ini", "utf8")
+	if b, _ := ioutil.ReadFile(dir + "/gm.ini"); string(b) != "[server]\r\nport=2\r\ninclude=common.ini\r\nhost=9\r\n" {
+		t.Errorf("Test_IniFileInclude failed, got %q", b)
+		return
+	}
+
+	h = NewIniFileWithOptions(IniFileOptions{IncludeKey: "include"})
+	if err = h.LoadWithError(dir+"/gm.ini", "utf8"); err != nil || h.GetString("server", "host", "") != "9" {
+		t.Errorf("Test_IniFileInclude failed, got %v", err)
+		return
+	}
+
+	cmd := NewIniFile()
+	cmd.SetString("log", "level", "debug")
+	m := MergeIniFiles(f, cmd)
+	src, _ = m.Provenance("server", "port")
+
+	g := NewIniFileWithOptions(IniFileOptions{IncludeKey: "include"})
+	if m.GetString("log", "level", "") == "debug" && src == dir+"/gs.ini:3" && g.LoadWithError(dir+"/loop.ini", "utf8") != nil {
+		t.Log("Test_IniFileInclude succeeded")
+	} else {
+		t.Error("Test_IniFileInclude failed")
+	}
+}
+
+func Test_IniFileInherit(t *testing.T) {
+	content := "[zone_base]\r\nmax_player=1000\r\nmap=1\r\n[pvp]\r\npk=true\r\n[zone2 : zone_base, pvp]\r\nmap=2\r\n[a : b]\r\n[b : a]\r\n"
+	f := newTestIniFileWithOptions(t, content, IniFileOptions{Inheritance: true})
+
+	if f.GetInt("zone2", "max_player", 0) != 1000 || f.GetInt("zone2", "map", 0) != 2 || !f.GetBool("zone2", "pk", false) || f.GetInt("a", "x", -1) != -1 {
+		t.Error("Test_IniFileInherit failed")
+		return
+	}
+
+	if keys := f.OverriddenKeys("zone2"); len(keys) != 1 || keys[0] != "map" {
+		t.Error("Test_IniFileInherit failed")
+		return
+	}
+
+	f.SetParents("zone2", "zone_base")
+	if s := saveTestIniFile(t, f); s != "[zone_base]\r\nmax_player=1000\r\nmap=1\r\n[pvp]\r\npk=true\r\n[zone2 : zone_base]\r\nmap=2\r\n[a : b]\r\n[b : a]\r\n" {
+		t.Errorf("Test_IniFileInherit failed, got %q", s)
+		return
+	}
+
+	// 默认不解析继承，section名中的':'保持原样
+	g := newTestIniFile(t, "[host:8080]\r\nweight=2\r\n")
+	g.SetString("a:b", "k", "v")
+	h := newTestIniFile(t, saveTestIniFile(t, g))
+	if h.GetInt("host:8080", "weight", 0) == 2 && h.GetString("a:b", "k", "") == "v" && len(h.GetParents("host:8080")) == 0 &&
+		strings.Join(h.Sections(), ",") == "host:8080,a:b" {
+		t.Log("Test_IniFileInherit succeeded")
+	} else {
+		t.Errorf("Test_IniFileInherit failed, got %v", h.Sections())
+	}
+}
+
+func Test_IniFileQuotedValue(t *testing.T) {
+	content := "[motd]\r\nprefix = \"  [GM] \"\r\nsep=\";\\t\"\r\nlist = a, \\\r\n       b, \\\r\n       c\r\ntext = <<END\r\nline 1\r\n  line 2\r\nEND\r\nport=1\r\n"
+	f := newTestIniFileWithOptions(t, content, IniFileOptions{LineContinuation: true})
+
+	if f.GetString("motd", "prefix", "") != "  [GM] " || f.GetString("motd", "sep", "") != ";\t" || f.GetString("motd", "list", "") != "a, b, c" ||
+		f.GetString("motd", "text", "") != "line 1\n  line 2" || f.GetInt("motd", "port", 0) != 1 {
+		t.Error("Test_IniFileQuotedValue failed")
+		return
+	}
+
+	if s := saveTestIniFile(t, f); s != content {
+		t.Errorf("Test_IniFileQuotedValue failed, got %q", s)
+		return
+	}
+
+	f.SetString("motd", "text", "hello\r\nworld ")
+	g := newTestIniFile(t, saveTestIniFile(t, f))
+	if g.GetString("motd", "text", "") != "hello\r\nworld " || g.GetInt("motd", "port", 0) != 1 {
+		t.Error("Test_IniFileQuotedValue failed")
+		return
+	}
+
+	// 默认不续行，以'\'结尾的windows路径保持原样
+	h := newTestIniFile(t, "[path]\r\nroot = C:\\data\\\r\nport = 9001\r\n")
+	if h.GetString("path", "root", "") == "C:\\data\\" && h.GetInt("path", "port", 0) == 9001 {
+		t.Log("Test_IniFileQuotedValue succeeded")
+	} else {
+		t.Error("Test_IniFileQuotedValue failed")
+	}
+}
+
+func Test_IniFileTypedValues(t *testing.T) {
+	content := "[server]\r\ntimeout = 1m30s\r\nbuffer = 64KB\r\nopen = 2024-05-01 10:00:00\r\nlevel = Warn\r\nlevels = debug, info, bad\r\n"
+	f := newTestIniFile(t, content)
+	RegisterTabEnum(NewTabEnum("LogLevel", map[string]int{"debug": 0, "info": 1, "warn": 2}))
+	levels := "LogLevel"
+
+	open := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	if f.GetDuration("server", "timeout", 0) != 90*time.Second || f.GetByteSize("server", "buffer", 0) != 64<<10 ||
+		!f.GetTime("server", "open", "", time.UTC, time.Time{}).Equal(open) || f.GetEnum("server", "level", levels, -1) != 2 {
+		t.Error("Test_IniFileTypedValues failed")
+		return
+	}
+
+	if v := f.GetEnums("server", "levels", ",", levels, -1); len(v) != 3 || v[0] != 0 || v[1] != 1 || v[2] != -1 {
+		t.Errorf("Test_IniFileTypedValues failed, got %v", v)
+		return
+	}
+
+	f.SetByteSizes("server", "buffers", []int64{1 << 20, 1536, 7}, ",")
+	f.SetDurations("server", "waits", []time.Duration{time.Second, time.Hour}, ",")
+	if !f.SetEnum("server", "level", levels, 1) || f.SetEnum("server", "level", levels, 9) {
+		t.Error("Test_IniFileTypedValues failed")
+		return
+	}
+
+	if _, err := ParseByteSize("16777216TB"); err == nil {
+		t.Error("Test_IniFileTypedValues failed")
+		return
+	}
+	if _, err := ParseByteSize("9223372036854775807.0B"); err == nil {
+		t.Error("Test_IniFileTypedValues failed")
+		return
+	}
+
+	b := f.GetByteSizes("server", "buffers", ",")
+	d := f.GetDurations("server", "waits", ",")
+	if f.GetString("server", "buffers", "") == "1MB,1536,7" && len(b) == 3 && b[1] == 1536 && len(d) == 2 && d[1] == time.Hour &&
+		f.GetString("server", "level", "") == "info" {
+		t.Log("Test_IniFileTypedValues succeeded")
+	} else {
+		t.Error("Test_IniFileTypedValues failed")
+	}
+}
+
+func Test_IniFileGeneric(t *testing.T) {
+	content := "[server]\r\nport = 9001\r\nratio = 0.5\r\ndebug = true\r\ntimeout = 5s\r\nip = 127.0.0.1\r\nids = 1, 2, 300\r\n"
+	f := newTestIniFile(t, content)
+
+	if IniGet(f, "server", "port", uint16(0)) != 9001 || IniGet(f, "server", "ratio", float32(0)) != 0.5 || !IniGet(f, "server", "debug", false) ||
+		IniGet(f, "server", "timeout", time.Duration(0)) != 5*time.Second || IniGet(f, "server", "ip", net.IP(nil)).String() != "127.0.0.1" ||
+		IniGet(f, "server", "ids", int8(-1)) != -1 {
+		t.Error("Test_IniFileGeneric failed")
+		return
+	}
+
+	if v := IniGetSlice[int16](f, "server", "ids", ","); len(v) != 3 || v[2] != 300 || IniGetSlice[int8](f, "server", "ids", ",") != nil {
+		t.Errorf("Test_IniFileGeneric failed, got %v", v)
+		return
+	}
+
+	open := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	IniSet(f, "server", "open", open)
+	IniSet(f, "server", "timeout", time.Minute)
+	IniSetSlice(f, "server", "ips", []net.IP{net.IPv4(10, 0, 0, 1), net.IPv4(10, 0, 0, 2)}, ";")
+	if f.GetString("server", "open", "") == "2024-05-01T10:00:00Z" && IniGet(f, "server", "open", time.Time{}).Equal(open) &&
+		f.GetString("server", "timeout", "") == "1m0s" && f.GetString("server", "ips", "") == "10.0.0.1;10.0.0.2" {
+		t.Log("Test_IniFileGeneric succeeded")
+	} else {
+		t.Error("Test_IniFileGeneric failed")
+	}
+}
+
+func Test_IniFileOrder(t *testing.T) {
+	content := "name=demo\r\n[server]\r\nport = 9001\r\nhost = 0.0.0.0\r\n\r\n[db : server]\r\n; account\r\nuser = root\r\n"
+	f := newTestIniFileWithOptions(t, content, IniFileOptions{Inheritance: true})
+
+	var s string
+	f.ForEach(func(sec string, key string, val string) bool {
+		s += sec + "." + key + "=" + val + ";"
+		return true
+	})
+	if s != ".name=demo;server.port=9001;server.host=0.0.0.0;db.user=root;" || len(f.Keys("db")) != 1 {
+		t.Errorf("Test_IniFileOrder failed, got %q", s)
+		return
+	}
+
+	if !f.RenameSection("server", "game") || f.RenameSection("db", "game") || !f.RenameKey("db", "user", "account") ||
+		!f.MoveKey("game", "host", "db", "account") || f.MoveKey("game", "host", "db", "") {
+		t.Error("Test_IniFileOrder failed")
+		return
+	}
+
+	expect := "name=demo\r\n[game]\r\nport = 9001\r\n\r\n[db : game]\r\nhost = 0.0.0.0\r\n; account\r\naccount = root\r\n"
+	if s := saveTestIniFile(t, f); s == expect && f.GetInt("db", "port", 0) == 9001 && strings.Join(f.Sections(), ",") == ",game,db" {
+		t.Log("Test_IniFileOrder succeeded")
+	} else {
+		t.Errorf("Test_IniFileOrder failed, got %q", s)
+	}
+}
+
+func Test_IniFileValidate(t *testing.T) {
+	schema := &IniSchema{
+		Strict: true,
+		Sections: []IniSectionSchema{
+			{Name: "server", Required: true, Keys: []IniKeySchema{
+				{Name: "port", Type: IniTypeInt, Required: true, Min: "1", Max: "65535"},
+				{Name: "timeout", Type: IniTypeDuration, Default: "30s", Max: "1m"},
+				{Name: "level", Type: IniTypeString, Default: "info", Allowed: []string{"debug", "info", "warn"}},
+				{Name: "ids", Type: IniTypeInt, Sep: ","},
+			}},
+			{Name: "db", Required: true, Keys: []IniKeySchema{{Name: "user", Required: true}}},
+		},
+	}
+
+	f := newTestIniFile(t, "[server]\r\nport = 70000\r\ntimeout = 2m\r\nids = 1, x\r\ndebug = 1\r\n")
+	err := f.Validate(schema)
+	es, ok := err.(IniSchemaErrors)
+	if !ok || len(es) != 5 || es[0].Key != "port" || es[4].Key != "debug" {
+		t.Errorf("Test_IniFileValidate failed, got %v", err)
+		return
+	}
+
+	f = newTestIniFile(t, "[server]\r\nport = 9001\r\n[db]\r\nuser = root\r\n")
+	if err := f.Validate(schema); err != nil {
+		t.Errorf("Test_IniFileValidate failed, got %v", err)
+		return
+	}
+
+	g := f.Defaulted(schema)
+	src, _ := g.Provenance("server", "timeout")
+	if g.GetDuration("server", "timeout", 0) == 30*time.Second && g.GetString("server", "level", "") == "info" && src == "<default>" &&
+		f.GetString("server", "timeout", "none") == "none" {
+		t.Log("Test_IniFileValidate succeeded")
+	} else {
+		t.Error("Test_IniFileValidate failed")
+	}
+}
+
+func Test_IniFileBindFlags(t *testing.T) {
+	schema := &IniSchema{Sections: []IniSectionSchema{
+		{Name: "server", Keys: []IniKeySchema{
+			{Name: "port", Type: IniTypeInt, Max: "65535", Usage: "listen port"},
+			{Name: "debug", Type: IniTypeBool, Default: "false"},
+		}},
+	}}
+
+	f := newTestIniFile(t, "[server]\r\nport = 9001\r\n[db]\r\nuser = root\r\n")
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	overrides := f.BindFlags(fs, schema)
+
+	if err := fs.Parse([]string{"-server.port", "70000"}); err == nil {
+		t.Error("Test_IniFileBindFlags failed")
+		return
+	}
+
+	if err := fs.Parse([]string{"-server.debug", "-set", "db.user=admin", "-set", "server.port=9002"}); err != nil {
+		t.Errorf("Test_IniFileBindFlags failed, got %v", err)
+		return
+	}
+
+	m := MergeIniFiles(f, overrides)
+	src, _ := m.Provenance("db", "user")
+	if m.GetBool("server", "debug", false) && m.GetString("db", "user", "") == "admin" && m.GetInt("server", "port", 0) == 9002 &&
+		src == IniFlagSource && fs.Lookup("server.port").DefValue == "9001" && f.GetInt("server", "port", 0) == 9001 &&
+		!strings.Contains(saveTestIniFile(t, f), "admin") {
+		t.Log("Test_IniFileBindFlags succeeded")
+	} else {
+		t.Error("Test_IniFileBindFlags failed")
+	}
+}
+
+func Test_IniFileBindFlagsInvalidName(t *testing.T) {
+	f := newTestIniFile(t, "-verbose = 1\r\n[server]\r\nport = 9001\r\n")
+	f.SetString("server", "a=b", "1")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	overrides := f.BindFlags(fs, nil)
+
+	err := fs.Parse([]string{"-server.port", "9002", "-set", "-verbose=2"})
+	if err == nil && fs.Lookup("-verbose") == nil && fs.Lookup("server.a=b") == nil && overrides.GetInt("server", "port", 0) == 9002 &&
+		overrides.GetInt("", "-verbose", 0) == 2 {
+		t.Log("Test_IniFileBindFlagsInvalidName succeeded")
+	} else {
+		t.Errorf("Test_IniFileBindFlagsInvalidName failed, got %v", err)
+	}
+}
+
+func Test_IniFileJSON(t *testing.T) {
+	f := newTestIniFile(t, "name=demo\r\n[server]\r\nport = 9001\r\nbuffer = 1KB\r\nids = 1,2\r\ndebug = yes\r\n[db]\r\nuser = \"<root>\"\r\n")
+
+	b, err := json.Marshal(f)
+	if err != nil || string(b) != `{"":{"name":"demo"},"server":{"port":"9001","buffer":"1KB","ids":"1,2","debug":"yes"},"db":{"user":"\u003croot\u003e"}}` {
+		t.Errorf("Test_IniFileJSON failed, got %s", b)
+		return
+	}
+
+	schema := &IniSchema{Sections: []IniSectionSchema{{Name: "server", Keys: []IniKeySchema{
+		{Name: "port", Type: IniTypeInt}, {Name: "buffer", Type: IniTypeByteSize}, {Name: "ids", Type: IniTypeInt, Sep: ","}, {Name: "debug", Type: IniTypeBool},
+	}}}}
+	if b, _ = f.ToJSON(schema); string(b) != `{"":{"name":"demo"},"server":{"port":9001,"buffer":1024,"ids":[1,2],"debug":true},"db":{"user":"\u003croot\u003e"}}` {
+		t.Errorf("Test_IniFileJSON failed, got %s", b)
+		return
+	}
+
+	if err = json.Unmarshal([]byte(`{"a":{"x":1},"b":{"y":[{}]}}`), f); err == nil || strings.Join(f.Sections(), ",") != ",server,db" {
+		t.Errorf("Test_IniFileJSON failed, got %v", f.Sections())
+		return
+	}
+
+	g := NewIniFile()
+	err = json.Unmarshal([]byte(`{"zone":{"id":3,"open":true,"tags":["a","b"]},"server":{"port":9002,"db":{"user":null}}}`), g)
+	if err != nil || strings.Join(g.Sections(), ",") != "zone,server,server.db" || strings.Join(g.Keys("zone"), ",") != "id,open,tags" ||
+		g.GetInt("server", "port", 0) != 9002 || g.GetString("zone", "tags", "") != "a,b" || !g.GetBool("zone", "open", false) {
+		t.Errorf("Test_IniFileJSON failed, got %v", err)
+		return
+	}
+
+	// 继承关系和重复的key经过JSON后保持不变
+	opts := IniFileOptions{Inheritance: true, DuplicateKey: IniDupKeyCollect}
+	h := newTestIniFileWithOptions(t, "[zone_base]\r\nmax_player=1000\r\n[zone2 : zone_base]\r\nmap=2\r\nip=a\r\nip=b\r\n", opts)
+	if b, err = json.Marshal(h); err != nil || string(b) != `{"zone_base":{"max_player":"1000"},"zone2":{"\u003cparents\u003e":["zone_base"],"map":"2","ip":["a","b"]}}` {
+		t.Errorf("Test_IniFileJSON failed, got %s", b)
+		return
+	}
+
+	k := NewIniFileWithOptions(opts)
+	if err = json.Unmarshal(b, k); err == nil && k.GetInt("zone2", "max_player", 0) == 1000 && strings.Join(k.GetValues("zone2", "ip"), ",") == "a,b" &&
+		strings.Join(k.GetParents("zone2"), ",") == "zone_base" && strings.Join(k.Keys("zone2"), ",") == "map,ip" {
+		t.Log("Test_IniFileJSON succeeded")
+	} else {
+		t.Errorf("Test_IniFileJSON failed, got %v", err)
+	}
+}
+
+func Test_IniFileEncoding(t *testing.T) {
+	if DetectEncoding([]byte("name=\xe5\x90\x8d")) != "utf8" || DetectEncoding([]byte("name=\xc3\xfb")) != "gbk" ||
+		DetectEncoding([]byte("\xff\xfen\x00")) != "utf16le" {
+		t.Error("Test_IniFileEncoding failed")
+		return
+	}
+
+	content := "\xef\xbb\xbf[server]\nname = \xe5\x90\x8d\n"
+	f := newTestIniFile(t, content)
+	f.SetInt("server", "port", 9001)
+	if s := saveTestIniFile(t, f); f.GetString("server", "name", "") != "\xe5\x90\x8d" || s != content+"port=9001\n" {
+		t.Errorf("Test_IniFileEncoding failed, got %q", s)
+		return
+	}
+
+	fi, _ := ioutil.TempFile("", "inifile")
+	fi.Close()
+	defer os.Remove(fi.Name())
+
+	g := NewIniFile()
+	g.SetString("server", "name", "\u540d")
+	if g.Save(fi.Name(), "gb2312x") {
+		t.Error("Test_IniFileEncoding failed")
+		return
+	}
+	g.Save(fi.Name(), "utf16le")
+	h := NewIniFile()
+	buff, _ := ioutil.ReadFile(fi.Name())
+	if !h.Load(fi.Name(), IniEncodingAuto) || h.Encoding() != "utf16le" || h.GetString("server", "name", "") != "\u540d" ||
+		h.LineEnding() != "\r\n" || string(buff[:4]) != "\xff\xfe[\x00" {
+		t.Errorf("Test_IniFileEncoding failed, got %q", buff)
+		return
+	}
+
+	// 自动检测时，被包含文件各自检测编码
+	dir := filepath.Dir(fi.Name())
+	common := filepath.Join(dir, filepath.Base(fi.Name())+".common")
+	defer os.Remove(common)
+	ioutil.WriteFile(common, []byte("[server]\nname = \xe5\x90\x8d\n"), 0644)
+	ioutil.WriteFile(fi.Name(), []byte(encodeUTF16("include = "+filepath.Base(common)+"\r\n", false)), 0644)
+
+	inc := NewIniFileWithOptions(IniFileOptions{IncludeKey: "include"})
+	if err := inc.LoadWithError(fi.Name(), IniEncodingAuto); err == nil && inc.Encoding() == "utf16le" && inc.GetString("server", "name", "") == "\u540d" {
+		t.Log("Test_IniFileEncoding succeeded")
+	} else {
+		t.Errorf("Test_IniFileEncoding failed, got %v", err)
+	}
+}
+
+func Test_IniFileSecret(t *testing.T) {
+	s, err := GenerateIniSecretKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("TEST_INI_KEY", s)
+	defer os.Unsetenv("TEST_INI_KEY")
+
+	key, err := IniSecretKeyFromEnv("TEST_INI_KEY")
+	if err != nil || len(key) != 32 {
+		t.Errorf("Test_IniFileSecret failed, got %v", err)
+		return
+	}
+
+	enc, _ := EncryptIniValue(key, "p@ss")
+	f := newTestIniFile(t, "[db]\r\npassword = "+enc+"\r\n")
+	if _, err = f.GetSecret("db", "password"); f.GetString("db", "password", "") != enc || err == nil {
+		t.Error("Test_IniFileSecret failed")
+		return
+	}
+
+	f.SetSecretKey(key)
+	f.SetSecret("db", "token", "abc")
+	g := newTestIniFileWithOptions(t, saveTestIniFile(t, f), IniFileOptions{Interpolate: true})
+	token := g.GetString("db", "token", "")
+	if !strings.HasPrefix(token, "ENC(") || g.GetString("db", "password", "") != enc {
+		t.Errorf("Test_IniFileSecret failed, got %q", token)
+		return
+	}
+
+	// 密码中的'$'不能被展开
+	g.SetSecretKey(key)
+	enc, _ = EncryptIniValue(key, "pa${HOME}ss")
+	g.SetString("db", "dollar", enc)
+	secret, err := g.GetSecret("db", "token")
+	if err == nil && secret == "abc" && g.GetString("db", "password", "") == "p@ss" && g.GetString("db", "dollar", "") == "pa${HOME}ss" {
+		t.Log("Test_IniFileSecret succeeded")
+	} else {
+		t.Errorf("Test_IniFileSecret failed, got %v", err)
+	}
+}
+
+func Test_IniFileSecretDisplay(t *testing.T) {
+	key := make([]byte, 32)
+	enc, _ := EncryptIniValue(key, "hunter2")
+	f := newTestIniFileWithOptions(t, "[db]\r\npassword = "+enc+"\r\ndsn = root:${db.password}@db\r\n", IniFileOptions{Interpolate: true, SecretKey: key})
+
+	var help, dump bytes.Buffer
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(&help)
+	f.BindFlags(fs, nil)
+	fs.PrintDefaults()
+	f.DumpProvenance(&dump)
+
+	if f.GetString("db", "dsn", "") == "root:hunter2@db" && strings.Contains(help.String(), enc) && strings.Contains(dump.String(), enc) &&
+		!strings.Contains(help.String(), "hunter2") && !strings.Contains(dump.String(), "hunter2") {
+		t.Log("Test_IniFileSecretDisplay succeeded")
+	} else {
+		t.Errorf("Test_IniFileSecretDisplay failed, got %q %q", help.String(), dump.String())
+	}
+}
+
+func Test_IniFileSecretReflect(t *testing.T) {
+	type DB struct {
+		User     string `ini:"user"`
+		Password string `ini:"password"`
+		Token    string `ini:"token"`
+	}
+
+	key := make([]byte, 32)
+	enc, _ := EncryptIniValue(key, "hunter2")
+	token, _ := EncryptIniValue(key, "abc")
+	f := newTestIniFileWithOptions(t, "[db]\r\nuser = root\r\npassword = "+enc+"\r\ntoken = "+token+"\r\n", IniFileOptions{SecretKey: key})
+
+	var db DB
+	if err := f.MapTo("db", &db); err != nil || db.Password != "hunter2" {
+		t.Errorf("Test_IniFileSecretReflect failed, got %v", err)
+		return
+	}
+
+	db.User, db.Token = "admin", "xyz"
+	err := f.ReflectFrom("db", &db)
+	s := saveTestIniFile(t, f)
+	secret, _ := f.GetSecret("db", "token")
+	if err == nil && strings.Contains(s, enc) && !strings.Contains(s, "hunter2") && !strings.Contains(s, "xyz") &&
+		secret == "xyz" && f.GetString("db", "user", "") == "admin" {
+		t.Log("Test_IniFileSecretReflect succeeded")
+	} else {
+		t.Errorf("Test_IniFileSecretReflect failed, got %v %q", err, s)
+	}
+}
+
+func Test_IniFileDiff(t *testing.T) {
+	a := newTestIniFile(t, "[Server]\r\nport = 9001\r\nhost = 0.0.0.0\r\n[old]\r\nk = v\r\n")
+	b := newTestIniFile(t, "[server]\r\nport = 9002\r\nmotd = \" hi \"\r\n[new]\r\nk = v\r\n")
+
+	d := Diff(a, b)
+	patch := "[Server]\n-port = 9001\n+port = 9002\n-host = 0.0.0.0\n+motd = \" hi \"\n-[old]\n+[new]\n+k = v\n"
+	if len(d) != 6 || d[0].Op != IniDiffChange || d[0].New != "9002" || d.String() != patch {
+		t.Errorf("Test_IniFileDiff failed, got %q", d.String())
+		return
+	}
+
+	p, err := ParseIniPatch(patch)
+	if err != nil || len(p) != len(d) || p[2] != d[2] {
+		t.Errorf("Test_IniFileDiff failed, got %v", err)
+		return
+	}
+
+	if err = a.ApplyPatch(patch); err != nil || len(Diff(a, b)) != 0 {
+		t.Errorf("Test_IniFileDiff failed, got %v", err)
+		return
+	}
+
+	if err = a.ApplyDiff(d); err == nil || a.GetInt("Server", "port", 0) != 9002 {
+		t.Error("Test_IniFileDiff failed")
+		return
+	}
+
+	// 加密的值以密文比较和应用
+	k, _ := GenerateIniSecretKey()
+	key, _ := decodeIniSecretKey(k)
+	old, _ := EncryptIniValue(key, "hunter2")
+	cur, _ := EncryptIniValue(key, "hunter3")
+	x := newTestIniFileWithOptions(t, "[db]\r\npassword = "+old+"\r\n", IniFileOptions{SecretKey: key})
+	y := newTestIniFileWithOptions(t, "[db]\r\npassword = "+cur+"\r\n", IniFileOptions{SecretKey: key})
+	patch = Diff(x, y).String()
+	if strings.Contains(patch, "hunter") || x.ApplyPatch(patch) != nil {
+		t.Errorf("Test_IniFileDiff failed, got %q", patch)
+		return
+	}
+
+	if s := saveTestIniFile(t, x); strings.Contains(s, cur) && !strings.Contains(s, "hunter") && x.GetString("db", "password", "") == "hunter3" {
+		t.Log("Test_IniFileDiff succeeded")
+	} else {
+		t.Errorf("Test_IniFileDiff failed, got %q", s)
+	}
+}
+
+func Test_SafeIniFileWatch(t *testing.T) {
+	s := NewSafeIniFile(newTestIniFile(t, "[server]\r\nport=9001\r\n"))
+
+	var changes []string
+	cancel := s.Watch("server", "port", func(old string, new string) {
+		changes = append(changes, old+"->"+new)
+	})
+
+	done := make(chan bool)
+	go func() {
+		for i := 0; i < 100; i++ {
+			s.GetInt("server", "port", 0)
+		}
+		done <- true
+	}()
+
+	s.SetString("server", "port", "9001")
+	s.SetString("server", "port", "9002")
+	s.Reload(newTestIniFile(t, "[server]\r\nport=9003\r\n"))
+	<-done
+
+	cancel()
+	s.SetString("server", "port", "9004")
+
+	if len(changes) == 2 && changes[0] == "9001->9002" && changes[1] == "9002->9003" && s.GetInt("server", "port", 0) == 9004 {
+		t.Log("Test_SafeIniFileWatch succeeded")
+	} else {
+		t.Errorf("Test_SafeIniFileWatch failed, got %v", changes)
+	}
+}
+
+func Test_IniWatcher(t *testing.T) {
+	fi, err := ioutil.TempFile("", "inifile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fi.WriteString("[server]\r\nport=9001\r\n")
+	fi.Close()
+	defer os.Remove(fi.Name())
+
+	f := NewIniFile()
+	if err = f.LoadWithError(fi.Name(), "utf8"); err != nil {
+		t.Fatal(err)
+	}
+
+	var errs []error
+	w, _ := NewIniWatcher(f)
+	w.Validate = func(f *IniFile) error {
+		if f.GetInt("server", "port", 0) <= 0 {
+			return errors.New("invalid port")
+		}
+		return nil
+	}
+	w.OnError = func(err error) { errs = append(errs, err) }
+
+	// 大小和修改时间都不变的改动也能发现
+	st, _ := os.Stat(fi.Name())
+	ioutil.WriteFile(fi.Name(), []byte("[server]\r\nport=9002\r\n"), 0644)
+	os.Chtimes(fi.Name(), st.ModTime(), st.ModTime())
+	if !w.Check() || w.Config().GetInt("server", "port", 0) != 9002 {
+		t.Error("Test_IniWatcher failed")
+		return
+	}
+
+	ioutil.WriteFile(fi.Name(), []byte("[server]\r\nport=-1\r\n"), 0644)
+	if w.Check() || w.Config().GetInt("server", "port", 0) != 9002 || len(errs) != 1 || w.Check() || len(errs) != 1 {
+		t.Error("Test_IniWatcher failed")
+		return
+	}
+
+	if w.Start(0) == nil || w.Start(time.Hour) != nil || w.Start(time.Hour) == nil {
+		t.Error("Test_IniWatcher failed")
+		return
+	}
+	w.Stop()
+	w.Stop()
+	if err := w.Start(time.Hour); err == nil {
+		w.Stop()
+		t.Log("Test_IniWatcher succeeded")
+	} else {
+		t.Error("Test_IniWatcher failed")
+	}
+}
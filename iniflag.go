@@ -0,0 +1,140 @@
+package goblazer
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// IniFlagSource is the provenance of values set by command line flags.
+const IniFlagSource = "<command line>"
+
+// BindFlags registers command line flags of f to 'fs':
+//
+//	-set sec.key=val  - overrides any key, can be repeated, a name without '.' is a global key
+//	-sec.key val      - a typed flag for each key of 'schema', or each key of f if 'schema' is nil
+//
+// Values are checked by 'schema' if it is not nil. f is not changed, values are set to the returned IniFile while
+// 'fs' is parsed with provenance IniFlagSource, use MergeIniFiles(f, overrides) to get the effective config. So a
+// later Save of f never writes command line overrides to file. The help of 'fs' lists keys with their current
+// values of f as defaults, ENC(...) values are listed as they are stored. A key whose name is not a valid flag name,
+// eg: "-verbose" or "a=b", has no typed flag and can only be overridden by -set.
+func (f *IniFile) BindFlags(fs *flag.FlagSet, schema *IniSchema) *IniFile {
+	overrides := NewIniFileWithOptions(f.options)
+	fs.Var(&iniSetFlag{f: overrides, schema: schema}, "set", "override ini key, eg: -set server.port=9001")
+
+	bind := func(sec string, key string, keySchema *IniKeySchema) {
+		name := joinIniSectionName(sec, key)
+		if !isValidFlagName(name) || fs.Lookup(name) != nil {
+			return
+		}
+
+		v := &iniKeyFlag{f: overrides, sec: sec, key: key, schema: keySchema}
+		v.val, _ = f.getDisplayKeyValue(sec, key)
+
+		usage := "ini key " + key
+		if sec != IniGlobalSection {
+			usage += " of section [" + sec + "]"
+		}
+		if keySchema != nil {
+			if v.val == "" {
+				v.val = keySchema.Default
+			}
+			if keySchema.Usage != "" {
+				usage = keySchema.Usage
+			}
+			usage = fmt.Sprintf("%s (%s)", usage, keySchema.Type)
+		}
+		fs.Var(v, name, usage)
+	}
+
+	if schema == nil {
+		f.ForEach(func(sec string, key string, val string) bool {
+			bind(sec, key, nil)
+			return true
+		})
+		return overrides
+	}
+
+	for _, secSchema := range schema.Sections {
+		for i := range secSchema.Keys {
+			bind(secSchema.Name, secSchema.Keys[i].Name, &secSchema.Keys[i])
+		}
+	}
+	return overrides
+}
+
+// isValidFlagName reports whether 'name' can be registered to a flag.FlagSet, which panics on names starting with
+// '-' or containing '='.
+func isValidFlagName(name string) bool {
+	return name != "" && !strings.HasPrefix(name, "-") && !strings.Contains(name, "=")
+}
+
+// setFlagValue checks and sets a value from command line.
+func (f *IniFile) setFlagValue(sec string, key string, val string, keySchema *IniKeySchema) error {
+	if keySchema != nil {
+		if err := keySchema.Check(val); err != nil {
+			return err
+		}
+	}
+
+	f.setKeyValueFrom(sec, key, val, IniFlagSource, 0)
+	return nil
+}
+
+// iniKeyFlag is the flag.Value of a key, 'f' is the IniFile of command line overrides.
+type iniKeyFlag struct {
+	f      *IniFile
+	sec    string
+	key    string
+	val    string
+	schema *IniKeySchema
+}
+
+func (v *iniKeyFlag) String() string {
+	return v.val
+}
+
+func (v *iniKeyFlag) Set(s string) error {
+	if err := v.f.setFlagValue(v.sec, v.key, s, v.schema); err != nil {
+		return err
+	}
+	v.val = s
+	return nil
+}
+
+// IsBoolFlag allows "-sec.key" without value for bool keys.
+func (v *iniKeyFlag) IsBoolFlag() bool {
+	return v.schema != nil && v.schema.Type == IniTypeBool
+}
+
+// iniSetFlag is the flag.Value of "-set sec.key=val".
+type iniSetFlag struct {
+	f      *IniFile
+	schema *IniSchema
+}
+
+func (v *iniSetFlag) String() string {
+	return ""
+}
+
+func (v *iniSetFlag) Set(s string) error {
+	i := strings.IndexByte(s, '=')
+	if i <= 0 {
+		return fmt.Errorf("%q is not in format sec.key=val", s)
+	}
+
+	sec, key := IniGlobalSection, strings.TrimSpace(s[:i])
+	if j := strings.LastIndexByte(key, '.'); j >= 0 {
+		sec, key = key[:j], key[j+1:]
+	}
+
+	var ok bool
+	var keySchema *IniKeySchema
+	if v.schema != nil {
+		if keySchema, ok = v.schema.FindKey(sec, key); !ok && v.schema.Strict {
+			return fmt.Errorf("unknown key %s", s[:i])
+		}
+	}
+	return v.f.setFlagValue(sec, key, s[i+1:], keySchema)
+}
@@ -0,0 +1,54 @@
+package goblazer
+
+import (
+	"reflect"
+	"strings"
+)
+
+// IniGet gets the value of 'key' as type T, 'dflt' if the key does not exist or can not be parsed. T can be any
+// builtin numeric type, bool, string, time.Duration or a type whose pointer implements encoding.TextUnmarshaler.
+func IniGet[T any](f *IniFile, sec string, key string, dflt T) T {
+	s, ok := f.getKeyValue(sec, key)
+	if !ok {
+		return dflt
+	}
+
+	var v T
+	if setIniScalarValue(reflect.ValueOf(&v).Elem(), strings.TrimSpace(s)) != nil {
+		return dflt
+	}
+	return v
+}
+
+// IniGetSlice gets values of 'key' split by 'sep' as type T, nil if the key does not exist or any value can not be
+// parsed.
+func IniGetSlice[T any](f *IniFile, sec string, key string, sep string) []T {
+	s, ok := f.getKeyValue(sec, key)
+	if !ok {
+		return nil
+	}
+
+	var v []T
+	if setIniFieldValue(reflect.ValueOf(&v).Elem(), s, sep) != nil {
+		return nil
+	}
+	return v
+}
+
+// IniSet sets the value of 'key' from type T, see IniGet for supported types.
+func IniSet[T any](f *IniFile, sec string, key string, val T) bool {
+	s, err := getIniScalarValue(reflect.ValueOf(&val).Elem())
+	if err != nil {
+		return false
+	}
+	return f.setKeyValue(sec, key, s)
+}
+
+// IniSetSlice sets values of 'key' joined by 'sep'.
+func IniSetSlice[T any](f *IniFile, sec string, key string, val []T, sep string) bool {
+	s, err := getIniFieldValue(reflect.ValueOf(val), sep)
+	if err != nil {
+		return false
+	}
+	return f.setKeyValue(sec, key, s)
+}
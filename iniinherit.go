@@ -0,0 +1,110 @@
+package goblazer
+
+import (
+	"sort"
+	"strings"
+)
+
+// SetParents sets parent sections of section 'sec'. Keys not existed in 'sec' are looked up in its parents in
+// order, and recursively in their parents, by GetString and friends. Save writes the header as "[sec : p1, p2]" only
+// if IniFileOptions.Inheritance is set, otherwise parents are not saved.
+func (f *IniFile) SetParents(sec string, parents ...string) {
+	secNode := f.addSecNode(sec)
+	secNode.Parents = nil
+
+	for _, p := range parents {
+		secNode.Parents = append(secNode.Parents, trimSectionName(p))
+	}
+}
+
+// GetParents returns parent sections of section 'sec'.
+func (f *IniFile) GetParents(sec string) []string {
+	if secNode, ok := f.SecNodes[f.formatSectionName(&sec)]; ok {
+		return append([]string(nil), secNode.Parents...)
+	}
+	return nil
+}
+
+// OverriddenKeys returns keys defined in section 'sec' which are also defined in any of its ancestors, in file
+// order.
+func (f *IniFile) OverriddenKeys(sec string) []string {
+	var ret []string
+
+	secNode, ok := f.SecNodes[f.formatSectionName(&sec)]
+	if !ok {
+		return nil
+	}
+
+	keyms := NewKeyNodesMapSorter(secNode.KeyNodes)
+	sort.Sort(keyms)
+
+	for _, key := range keyms {
+		visited := map[string]bool{f.formatSectionName(&sec): true}
+		for _, p := range secNode.Parents {
+			if _, n := f.findInheritedKeyNode(p, f.formatKeyName(key.Name), visited); n != nil {
+				ret = append(ret, key.Name)
+				break
+			}
+		}
+	}
+
+	return ret
+}
+
+// header returns the header line of section, with its parents if 'inherit' is true.
+func (n *IniFileSecNode) header(inherit bool) string {
+	if !inherit || len(n.Parents) == 0 {
+		return n.Name
+	}
+	return strings.TrimSuffix(n.Name, "]") + " : " + strings.Join(n.Parents, ", ") + "]"
+}
+
+// splitSectionParents splits header "[name : p1, p2]" into "[name]" and parents.
+func splitSectionParents(header string) (string, []string) {
+	inner := header[1 : len(header)-1]
+
+	i := strings.IndexByte(inner, ':')
+	if i < 0 {
+		return header, nil
+	}
+
+	var parents []string
+	for _, p := range strings.Split(inner[i+1:], ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			parents = append(parents, p)
+		}
+	}
+
+	return "[" + strings.TrimSpace(inner[:i]) + "]", parents
+}
+
+// findInheritedKeyNode finds formatted key 'key' in section 'sec' and then in its ancestors by depth-first order.
+// 'visited' holds sections already searched, to stop at inheritance cycles.
+func (f *IniFile) findInheritedKeyNode(sec string, key string, visited map[string]bool) (*IniFileSecNode, *IniFileKeyNode) {
+	name := f.formatSectionName(&sec)
+	secNode, ok := f.SecNodes[name]
+	if !ok || visited[name] {
+		return nil, nil
+	}
+
+	if keyNode, ok := secNode.KeyNodes[key]; ok {
+		return secNode, keyNode
+	}
+
+	if len(secNode.Parents) == 0 {
+		return nil, nil
+	}
+
+	if visited == nil {
+		visited = make(map[string]bool)
+	}
+	visited[name] = true
+
+	for _, p := range secNode.Parents {
+		if pn, kn := f.findInheritedKeyNode(p, key, visited); kn != nil {
+			return pn, kn
+		}
+	}
+
+	return nil, nil
+}
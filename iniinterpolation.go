@@ -0,0 +1,116 @@
+package goblazer
+
+import (
+	"os"
+	"strings"
+	"unicode"
+)
+
+// EnvOverrideName returns the name of env var overriding key 'key' of section 'sec', eg: "GOBLAZER_SERVER_PORT"
+// for prefix "GOBLAZER", section "server" and key "port". Characters other than letters and digits become '_'.
+func EnvOverrideName(prefix string, sec string, key string) string {
+	sec = trimSectionName(sec)
+
+	parts := []string{prefix}
+	if sec != IniGlobalSection {
+		parts = append(parts, sec)
+	}
+	parts = append(parts, key)
+
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToUpper(r)
+		}
+		return '_'
+	}, strings.Join(parts, "_"))
+}
+
+func (f *IniFile) getEnvKeyValue(sec string, key string) (string, bool) {
+	if f.options.EnvPrefix == "" {
+		return "", false
+	}
+	return os.LookupEnv(EnvOverrideName(f.options.EnvPrefix, sec, key))
+}
+
+// resolveKeyValue returns the value of key with all references expanded. 'visiting' holds the keys being resolved,
+// a reference to any of them is a cycle and is left as it is.
+func (f *IniFile) resolveKeyValue(sec string, key string, visiting map[string]bool) (string, bool) {
+	s, ok := f.getRawKeyValue(sec, key)
+	if ok && isIniSecretValue(s) { // 解密后的值不再展开，以免密码中的'$'被替换
+		if visiting[iniMaskSecretsID] { // 用于显示，保留密文
+			return s, true
+		}
+		return f.decryptKeyValue(s), true
+	}
+	if !ok || !f.options.Interpolate || !strings.ContainsAny(s, "$%") {
+		return s, ok
+	}
+
+	id := f.formatSectionName(&sec) + "\x00" + f.formatKeyName(key)
+	if visiting == nil {
+		visiting = make(map[string]bool)
+	}
+	visiting[id] = true
+	defer delete(visiting, id)
+
+	return f.interpolate(sec, s, visiting), true
+}
+
+func (f *IniFile) interpolate(sec string, s string, visiting map[string]bool) string {
+	var buff strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		var ref, val string
+		var end int
+		var ok bool
+
+		switch {
+		case strings.HasPrefix(s[i:], "${"):
+			if end = strings.IndexByte(s[i:], '}'); end > 0 {
+				ref = s[i+2 : i+end]
+				val, ok = f.resolveReference(sec, ref, visiting)
+			}
+		case strings.HasPrefix(s[i:], "%("):
+			if end = strings.Index(s[i:], ")s"); end > 0 {
+				ref = s[i+2 : i+end]
+				end++ // 跳过's'
+				val, ok = f.resolveLocalReference(sec, ref, visiting)
+			}
+		}
+
+		if !ok { // 不是引用或无法解析，原样保留
+			buff.WriteByte(s[i])
+			continue
+		}
+
+		buff.WriteString(val)
+		i += end
+	}
+
+	return buff.String()
+}
+
+// resolveReference resolves ${section.key} or ${ENV_VAR}. Since names of section and key may contain '.', every
+// '.' is tried from right to left.
+func (f *IniFile) resolveReference(sec string, ref string, visiting map[string]bool) (string, bool) {
+	for i := strings.LastIndexByte(ref, '.'); i > 0; i = strings.LastIndexByte(ref[:i], '.') {
+		s, k := ref[:i], ref[i+1:]
+		if _, keyNode := f.findKeyNode(s, k); keyNode != nil {
+			return f.resolveLocalReference(s, k, visiting)
+		}
+	}
+
+	return os.LookupEnv(ref)
+}
+
+// resolveLocalReference resolves %(key)s in section 'sec'. Keys of global section can be referred too.
+func (f *IniFile) resolveLocalReference(sec string, key string, visiting map[string]bool) (string, bool) {
+	if _, keyNode := f.findKeyNode(sec, key); keyNode == nil {
+		sec = IniGlobalSection
+	}
+
+	if visiting[f.formatSectionName(&sec)+"\x00"+f.formatKeyName(key)] { // 循环引用
+		return "", false
+	}
+	return f.resolveKeyValue(sec, key, visiting)
+}
@@ -0,0 +1,300 @@
+package goblazer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// IniJSONParentsKey is the reserved member of a section object holding its parent sections, eg:
+// {"zone2":{"<parents>":["zone_base"],"map":"2"}}, see IniFile.SetParents.
+const IniJSONParentsKey = "<parents>"
+
+// MarshalJSON encodes f as a JSON object of sections to objects of keys to string values in file order, eg:
+// {"":{"name":"demo"},"server":{"port":"9001"}}. The global section is "" and omitted if it does not exist. Parents
+// of a section are the member IniJSONParentsKey, and all values of a repeated key kept by IniDupKeyCollect policy
+// are an array.
+func (f *IniFile) MarshalJSON() ([]byte, error) {
+	return f.ToJSON(nil)
+}
+
+// ToJSON encodes f like MarshalJSON, values of keys in 'schema' are typed: int, float and byte size are numbers,
+// bool is true or false and a list with separator is an array. A value which does not match its type is kept as
+// string.
+func (f *IniFile) ToJSON(schema *IniSchema) ([]byte, error) {
+	var buff bytes.Buffer
+
+	secms := NewSecNodesMapSorter(f.SecNodes)
+	sort.Sort(secms)
+
+	buff.WriteByte('{')
+	for i, sec := range secms {
+		if i > 0 {
+			buff.WriteByte(',')
+		}
+
+		name := trimSectionName(sec.Name)
+		writeJSONString(&buff, name)
+		buff.WriteString(":{")
+
+		if len(sec.Parents) > 0 {
+			writeJSONString(&buff, IniJSONParentsKey)
+			buff.WriteByte(':')
+			writeJSONStrings(&buff, sec.Parents)
+		}
+
+		keyms := NewKeyNodesMapSorter(sec.KeyNodes)
+		sort.Sort(keyms)
+
+		for j, key := range keyms {
+			if j > 0 || len(sec.Parents) > 0 {
+				buff.WriteByte(',')
+			}
+
+			writeJSONString(&buff, key.Name)
+			buff.WriteByte(':')
+
+			var keySchema *IniKeySchema
+			if schema != nil {
+				keySchema, _ = schema.FindKey(name, key.Name)
+			}
+
+			var shadows KeyNodesMapSorter
+			if f.options.DuplicateKey == IniDupKeyCollect {
+				shadows = f.sortedShadows(sec, key.Name)
+			}
+			if len(shadows) == 0 {
+				writeJSONValue(&buff, key.Value, keySchema)
+				continue
+			}
+
+			// 重复的key输出为数组
+			buff.WriteByte('[')
+			writeJSONValue(&buff, key.Value, keySchema)
+			for _, n := range shadows {
+				buff.WriteByte(',')
+				writeJSONValue(&buff, n.Value, keySchema)
+			}
+			buff.WriteByte(']')
+		}
+		buff.WriteByte('}')
+	}
+	buff.WriteByte('}')
+
+	return buff.Bytes(), nil
+}
+
+// UnmarshalJSON replaces contents of f with a JSON object in the format of MarshalJSON, sections and keys keep the
+// order of JSON. Numbers and bools are stored as their text, arrays are joined by ",", or are repeated values if f
+// uses IniDupKeyCollect policy, null is an empty value and a nested object is the sub-section "sec.name". f is not
+// changed if it returns an error.
+func (f *IniFile) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	if err := expectJSONDelim(dec, '{'); err != nil {
+		return err
+	}
+
+	// 先解析到新的IniFile，成功后再替换f的内容
+	g := NewIniFileWithOptions(f.options)
+	for dec.More() {
+		sec, err := readJSONKey(dec)
+		if err != nil {
+			return err
+		}
+		if err = g.readJSONSection(dec, sec); err != nil {
+			return err
+		}
+	}
+
+	if err := expectJSONDelim(dec, '}'); err != nil {
+		return err
+	}
+
+	f.removeLinks()
+	f.SecNodes = g.SecNodes
+	f.seqNoCounter = g.seqNoCounter
+	return nil
+}
+
+func (f *IniFile) readJSONSection(dec *json.Decoder, sec string) error {
+	if err := expectJSONDelim(dec, '{'); err != nil {
+		return err
+	}
+	f.addSecNode(sec)
+
+	for dec.More() {
+		key, err := readJSONKey(dec)
+		if err != nil {
+			return err
+		}
+
+		var raw json.RawMessage
+		if err = dec.Decode(&raw); err != nil {
+			return err
+		}
+
+		if key == IniJSONParentsKey {
+			var parents []string
+			if err = json.Unmarshal(raw, &parents); err != nil {
+				return fmt.Errorf("ini: section [%s] %s: %v", sec, key, err)
+			}
+			f.SetParents(sec, parents...)
+			continue
+		}
+
+		if bytes.HasPrefix(raw, []byte("{")) {
+			sub := json.NewDecoder(bytes.NewReader(raw))
+			sub.UseNumber()
+			if err = f.readJSONSection(sub, joinIniSectionName(sec, key)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		vals, err := decodeJSONValue(raw)
+		if err != nil {
+			return fmt.Errorf("ini: section [%s] key %s: %v", sec, key, err)
+		}
+
+		if len(vals) > 1 && f.options.DuplicateKey == IniDupKeyCollect {
+			f.SetValues(sec, key, vals)
+		} else {
+			f.setKeyValue(sec, key, strings.Join(vals, ","))
+		}
+	}
+
+	return expectJSONDelim(dec, '}')
+}
+
+func expectJSONDelim(dec *json.Decoder, delim json.Delim) error {
+	t, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if d, ok := t.(json.Delim); !ok || d != delim {
+		return fmt.Errorf("ini: expect %v in JSON, got %v", delim, t)
+	}
+	return nil
+}
+
+func readJSONKey(dec *json.Decoder) (string, error) {
+	t, err := dec.Token()
+	if err != nil {
+		return "", err
+	}
+	return t.(string), nil // 对象中的key一定是字符串
+}
+
+// decodeJSONValue converts a JSON scalar or array to ini values, one value for each element of array.
+func decodeJSONValue(raw json.RawMessage) ([]string, error) {
+	var v interface{}
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	if a, ok := v.([]interface{}); ok {
+		strs := make([]string, len(a))
+		for i, e := range a {
+			s, ok := jsonScalarString(e)
+			if !ok {
+				return nil, fmt.Errorf("unsupported JSON array element %s", raw)
+			}
+			strs[i] = s
+		}
+		return strs, nil
+	}
+
+	s, ok := jsonScalarString(v)
+	if !ok {
+		return nil, fmt.Errorf("unsupported JSON value %s", raw)
+	}
+	return []string{s}, nil
+}
+
+func jsonScalarString(v interface{}) (string, bool) {
+	switch e := v.(type) {
+	case nil:
+		return "", true
+	case string:
+		return e, true
+	case json.Number:
+		return e.String(), true
+	case bool:
+		return strconv.FormatBool(e), true
+	}
+	return "", false
+}
+
+func writeJSONString(buff *bytes.Buffer, s string) {
+	b, _ := json.Marshal(s)
+	buff.Write(b)
+}
+
+func writeJSONStrings(buff *bytes.Buffer, strs []string) {
+	buff.WriteByte('[')
+	for i, s := range strs {
+		if i > 0 {
+			buff.WriteByte(',')
+		}
+		writeJSONString(buff, s)
+	}
+	buff.WriteByte(']')
+}
+
+func writeJSONValue(buff *bytes.Buffer, val string, keySchema *IniKeySchema) {
+	if keySchema == nil {
+		writeJSONString(buff, val)
+		return
+	}
+
+	if keySchema.Sep == "" {
+		writeJSONTypedValue(buff, val, keySchema.Type)
+		return
+	}
+
+	buff.WriteByte('[')
+	if val != "" {
+		for i, s := range strings.Split(val, keySchema.Sep) {
+			if i > 0 {
+				buff.WriteByte(',')
+			}
+			writeJSONTypedValue(buff, strings.TrimSpace(s), keySchema.Type)
+		}
+	}
+	buff.WriteByte(']')
+}
+
+func writeJSONTypedValue(buff *bytes.Buffer, val string, t IniValueType) {
+	switch t {
+	case IniTypeInt:
+		if n, err := strconv.ParseInt(val, 10, 64); err == nil {
+			buff.WriteString(strconv.FormatInt(n, 10))
+			return
+		}
+	case IniTypeFloat:
+		if n, err := strconv.ParseFloat(val, 64); err == nil && !math.IsInf(n, 0) && !math.IsNaN(n) {
+			buff.WriteString(strconv.FormatFloat(n, 'g', -1, 64))
+			return
+		}
+	case IniTypeByteSize:
+		if n, err := ParseByteSize(val); err == nil {
+			buff.WriteString(strconv.FormatInt(n, 10))
+			return
+		}
+	case IniTypeBool:
+		if IsTrueString(val) || IsFalseString(val) {
+			buff.WriteString(strconv.FormatBool(IsTrueString(val)))
+			return
+		}
+	}
+	writeJSONString(buff, val)
+}
@@ -0,0 +1,105 @@
+package goblazer
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+)
+
+// MergeIniFiles stacks 'layers' from the lowest priority to the highest, eg: defaults, environment, host and
+// command line, into a new IniFile. Every value keeps the file and line it comes from, see Provenance. The new
+// IniFile uses the options of the first layer.
+func MergeIniFiles(layers ...*IniFile) *IniFile {
+	var f *IniFile
+
+	if len(layers) > 0 {
+		f = NewIniFileWithOptions(layers[0].options)
+	} else {
+		f = NewIniFile()
+	}
+
+	for _, l := range layers {
+		f.Merge(l)
+	}
+	return f
+}
+
+// Merge copies all sections and keys of 'o' into f, values of 'o' override values of f.
+func (f *IniFile) Merge(o *IniFile) {
+	secms := NewSecNodesMapSorter(o.SecNodes)
+	sort.Sort(secms)
+
+	for _, sec := range secms {
+		if len(sec.Parents) > 0 {
+			f.SetParents(sec.Name, sec.Parents...)
+		} else {
+			f.addSecNode(sec.Name)
+		}
+
+		keyms := NewKeyNodesMapSorter(sec.KeyNodes)
+		sort.Sort(keyms)
+
+		for _, key := range keyms {
+			f.setKeyValueFrom(sec.Name, key.Name, key.Value, key.File, key.Line)
+		}
+	}
+
+	f.files = append(f.files, o.files...)
+}
+
+// Files returns paths of all files loaded into f in load order, including included files and files of merged
+// IniFiles.
+func (f *IniFile) Files() []string {
+	return append([]string(nil), f.files...)
+}
+
+// Provenance returns where the effective value of key comes from: "file:line" for a value loaded from file,
+// "env:NAME" for a value overridden by env var, a tag like "<default>" for a value set by program with a source, or
+// "" for a value set by program. It returns false if key does not exist.
+func (f *IniFile) Provenance(sec string, key string) (string, bool) {
+	if _, ok := f.getEnvKeyValue(sec, key); ok {
+		return "env:" + EnvOverrideName(f.options.EnvPrefix, sec, key), true
+	}
+
+	_, keyNode := f.findKeyNode(sec, key)
+	if keyNode == nil {
+		return "", false
+	}
+
+	if keyNode.Line <= 0 {
+		return keyNode.File, true
+	}
+	return keyNode.File + ":" + strconv.Itoa(keyNode.Line), true
+}
+
+// DumpProvenance writes all effective values with their provenance to 'w' in file order. ENC(...) values are
+// written as they are stored.
+func (f *IniFile) DumpProvenance(w io.Writer) {
+	secms := NewSecNodesMapSorter(f.SecNodes)
+	sort.Sort(secms)
+
+	for _, sec := range secms {
+		if sec.Name != IniGlobalSection {
+			fmt.Fprintf(w, "%s\n", sec.Name)
+		}
+
+		keyms := NewKeyNodesMapSorter(sec.KeyNodes)
+		sort.Sort(keyms)
+
+		for _, key := range keyms {
+			val, _ := f.getDisplayKeyValue(sec.Name, key.Name)
+			src, _ := f.Provenance(sec.Name, key.Name)
+			if src == "" {
+				src = "<program>"
+			}
+			fmt.Fprintf(w, "%s = %s    ; %s\n", key.Name, val, src)
+		}
+	}
+}
+
+// PrintProvenance prints all effective values with their provenance to stdout.
+func (f *IniFile) PrintProvenance() {
+	f.DumpProvenance(os.Stdout)
+}
@@ -0,0 +1,282 @@
+package goblazer
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	iniDurationType        = reflect.TypeOf(time.Duration(0))
+	iniTextMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	iniTextUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// MapTo fills the struct pointed by 'v' with keys of section 'sec'. Fields are matched by tag `ini:"key"`, or by
+// field name if the tag is absent, `ini:"-"` skips a field. Supported field types are string, bool, all integer and
+// float types, time.Duration, types implementing encoding.TextUnmarshaler and slices of them. Other tags of field:
+//
+//	default:"value" - used when the key does not exist
+//	sep:";"         - separator of slice values, default is ","
+//
+// A nested struct field is mapped to sub-section "sec.name", eg: field `ini:"db"` of section "server" is mapped to
+// section "[server.db]". An anonymous struct field is mapped to the same section.
+func (f *IniFile) MapTo(sec string, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("ini: MapTo needs a pointer to struct, got %T", v)
+	}
+
+	return f.mapTo(sec, rv.Elem())
+}
+
+// ReflectFrom writes all fields of struct 'v' into section 'sec' with the same rules as MapTo. A key holding an
+// ENC(...) value stays encrypted, see SetSecret.
+func (f *IniFile) ReflectFrom(sec string, v interface{}) error {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("ini: ReflectFrom needs a struct, got %T", v)
+	}
+
+	return f.reflectFrom(sec, rv)
+}
+
+func (f *IniFile) mapTo(sec string, rv reflect.Value) error {
+	rt := rv.Type()
+
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		key, ok := iniFieldKey(field)
+		if !ok {
+			continue
+		}
+
+		fv := rv.Field(i)
+		if fv.Kind() == reflect.Struct && !isIniTextType(fv.Type()) {
+			sub := sec
+			if !field.Anonymous {
+				sub = joinIniSectionName(sec, key)
+			}
+			if err := f.mapTo(sub, fv); err != nil {
+				return err
+			}
+			continue
+		}
+
+		s, ok := f.getKeyValue(sec, key)
+		if !ok {
+			if s, ok = field.Tag.Lookup("default"); !ok {
+				continue
+			}
+		}
+
+		if err := setIniFieldValue(fv, s, iniFieldSep(field)); err != nil {
+			return fmt.Errorf("ini: section %s key %s: %v", sec, key, err)
+		}
+	}
+
+	return nil
+}
+
+func (f *IniFile) reflectFrom(sec string, rv reflect.Value) error {
+	rt := rv.Type()
+
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		key, ok := iniFieldKey(field)
+		if !ok {
+			continue
+		}
+
+		fv := rv.Field(i)
+		if fv.Kind() == reflect.Struct && !isIniTextType(fv.Type()) {
+			sub := sec
+			if !field.Anonymous {
+				sub = joinIniSectionName(sec, key)
+			}
+			if err := f.reflectFrom(sub, fv); err != nil {
+				return err
+			}
+			continue
+		}
+
+		s, err := getIniFieldValue(fv, iniFieldSep(field))
+		if err != nil {
+			return fmt.Errorf("ini: section %s key %s: %v", sec, key, err)
+		}
+		if err = f.setFieldKeyValue(sec, key, s); err != nil {
+			return fmt.Errorf("ini: section %s key %s: %v", sec, key, err)
+		}
+	}
+
+	return nil
+}
+
+// setFieldKeyValue sets a value written by ReflectFrom. An ENC(...) value stays encrypted: it is kept if the field
+// still holds its plaintext, or it is encrypted again by SetSecret.
+func (f *IniFile) setFieldKeyValue(sec string, key string, s string) error {
+	old, ok := f.getStoredKeyValue(sec, key)
+	if !ok || !isIniSecretValue(old) {
+		f.setKeyValue(sec, key, s)
+		return nil
+	}
+
+	if s == old || s == f.decryptKeyValue(old) {
+		return nil
+	}
+	if !f.SetSecret(sec, key, s) {
+		return errors.New("can not encrypt secret value")
+	}
+	return nil
+}
+
+// iniFieldKey returns the key name of a struct field, false if the field should be skipped.
+func iniFieldKey(field reflect.StructField) (string, bool) {
+	if field.PkgPath != "" && !field.Anonymous { // 非导出字段
+		return "", false
+	}
+
+	key := field.Tag.Get("ini")
+	if i := strings.IndexByte(key, ','); i >= 0 {
+		key = key[:i]
+	}
+
+	if key == "-" {
+		return "", false
+	}
+	if key == "" {
+		key = field.Name
+	}
+	return key, true
+}
+
+func iniFieldSep(field reflect.StructField) string {
+	if sep, ok := field.Tag.Lookup("sep"); ok {
+		return sep
+	}
+	return ","
+}
+
+func joinIniSectionName(sec string, sub string) string {
+	sec = trimSectionName(sec)
+	if sec == IniGlobalSection {
+		return sub
+	}
+	return sec + "." + sub
+}
+
+// isIniTextType reports whether values of 't' are read and written as a whole text, eg: time.Time or net.IP.
+func isIniTextType(t reflect.Type) bool {
+	return reflect.PtrTo(t).Implements(iniTextUnmarshalerType)
+}
+
+func setIniFieldValue(fv reflect.Value, s string, sep string) error {
+	if fv.Kind() != reflect.Slice || isIniTextType(fv.Type()) {
+		return setIniScalarValue(fv, s)
+	}
+
+	if s == "" {
+		fv.Set(reflect.MakeSlice(fv.Type(), 0, 0))
+		return nil
+	}
+
+	strs := strings.Split(s, sep)
+	sv := reflect.MakeSlice(fv.Type(), len(strs), len(strs))
+	for i, str := range strs {
+		if err := setIniScalarValue(sv.Index(i), strings.TrimSpace(str)); err != nil {
+			return err
+		}
+	}
+	fv.Set(sv)
+	return nil
+}
+
+func getIniFieldValue(fv reflect.Value, sep string) (string, error) {
+	if fv.Kind() != reflect.Slice || isIniTextType(fv.Type()) {
+		return getIniScalarValue(fv)
+	}
+
+	strs := make([]string, fv.Len())
+	for i := range strs {
+		s, err := getIniScalarValue(fv.Index(i))
+		if err != nil {
+			return "", err
+		}
+		strs[i] = s
+	}
+	return strings.Join(strs, sep), nil
+}
+
+func setIniScalarValue(v reflect.Value, s string) error {
+	if isIniTextType(v.Type()) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+	if v.Type() == iniDurationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		v.SetBool(IsTrueString(s))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+func getIniScalarValue(v reflect.Value) (string, error) {
+	if v.Type().Implements(iniTextMarshalerType) {
+		buff, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(buff), err
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(iniTextMarshalerType) {
+		buff, err := v.Addr().Interface().(encoding.TextMarshaler).MarshalText()
+		return string(buff), err
+	}
+	if v.Type() == iniDurationType {
+		return time.Duration(v.Int()).String(), nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return GetBoolString(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()), nil
+	}
+	return "", fmt.Errorf("unsupported type %s", v.Type())
+}
@@ -0,0 +1,196 @@
+package goblazer
+
+import (
+	"sort"
+	"strings"
+)
+
+// Sections returns names of all sections without brackets in file order, the global section "" comes first if it
+// exists.
+func (f *IniFile) Sections() []string {
+	secms := NewSecNodesMapSorter(f.SecNodes)
+	sort.Sort(secms)
+
+	ret := make([]string, len(secms))
+	for i, n := range secms {
+		ret[i] = trimSectionName(n.Name)
+	}
+	return ret
+}
+
+// Keys returns names of keys defined in section 'sec' in file order, keys inherited from parent sections are not
+// included.
+func (f *IniFile) Keys(sec string) []string {
+	secNode, ok := f.SecNodes[f.formatSectionName(&sec)]
+	if !ok {
+		return nil
+	}
+
+	keyms := NewKeyNodesMapSorter(secNode.KeyNodes)
+	sort.Sort(keyms)
+
+	ret := make([]string, len(keyms))
+	for i, n := range keyms {
+		ret[i] = n.Name
+	}
+	return ret
+}
+
+// ForEach calls 'fn' for each key of each section in file order until 'fn' returns false. 'val' is the value
+// returned by GetString.
+func (f *IniFile) ForEach(fn func(sec string, key string, val string) bool) {
+	for _, sec := range f.Sections() {
+		for _, key := range f.Keys(sec) {
+			val, _ := f.getKeyValue(sec, key)
+			if !fn(sec, key, val) {
+				return
+			}
+		}
+	}
+}
+
+// RenameSection renames section 'sec' to 'name' in place, parents of other sections are renamed too. It returns
+// false if 'sec' does not exist or 'name' already exists.
+func (f *IniFile) RenameSection(sec string, name string) bool {
+	if sec == IniGlobalSection || name == IniGlobalSection {
+		return false
+	}
+
+	oldName := f.formatSectionName(&sec)
+	newName := f.formatSectionName(&name)
+	secNode, ok := f.SecNodes[oldName]
+	if !ok {
+		return false
+	}
+	if _, ok = f.SecNodes[newName]; ok && newName != oldName {
+		return false
+	}
+
+	delete(f.SecNodes, oldName)
+	secNode.Name = name
+	if secNode.raw != "" {
+		secNode.raw = secNode.header(f.options.Inheritance)
+		secNode.rawParents = strings.Join(secNode.Parents, ",")
+	}
+	f.SecNodes[newName] = secNode
+
+	// 修改子section的继承关系
+	for _, n := range f.SecNodes {
+		for i, p := range n.Parents {
+			if f.formatSectionName(&p) == oldName {
+				n.Parents[i] = trimSectionName(name)
+			}
+		}
+	}
+	return true
+}
+
+// RenameKey renames key 'key' of section 'sec' to 'name' in place, the value and comments are kept. It returns false
+// if 'key' does not exist or 'name' already exists.
+func (f *IniFile) RenameKey(sec string, key string, name string) bool {
+	secNode, ok := f.SecNodes[f.formatSectionName(&sec)]
+	if !ok {
+		return false
+	}
+
+	oldName := f.formatKeyName(key)
+	newName := f.formatKeyName(name)
+	keyNode, ok := secNode.KeyNodes[oldName]
+	if !ok {
+		return false
+	}
+	if _, ok = secNode.KeyNodes[newName]; ok && newName != oldName {
+		return false
+	}
+
+	delete(secNode.KeyNodes, oldName)
+	secNode.KeyNodes[newName] = keyNode
+	renameIniKeyNode(keyNode, name)
+	for _, n := range secNode.shadows {
+		if f.formatKeyName(n.Name) == oldName {
+			renameIniKeyNode(n, name)
+		}
+	}
+	return true
+}
+
+// MoveKey moves key 'key' of section 'sec' to section 'dst' before key 'before', or to the end of 'dst' if 'before'
+// is empty or not found. Section 'dst' is created if it does not exist. It returns false if 'key' does not exist or
+// 'dst' already has another key with the same name.
+func (f *IniFile) MoveKey(sec string, key string, dst string, before string) bool {
+	srcNode, ok := f.SecNodes[f.formatSectionName(&sec)]
+	if !ok {
+		return false
+	}
+
+	name := f.formatKeyName(key)
+	keyNode, ok := srcNode.KeyNodes[name]
+	if !ok {
+		return false
+	}
+
+	dstNode := f.addSecNode(dst)
+	if n, ok := dstNode.KeyNodes[name]; ok && n != keyNode {
+		return false
+	}
+
+	// 重复的key跟随移动
+	moved := KeyNodesMapSorter{keyNode}
+	shadows := srcNode.shadows[:0]
+	for _, n := range srcNode.shadows {
+		if f.formatKeyName(n.Name) == name {
+			moved = append(moved, n)
+		} else {
+			shadows = append(shadows, n)
+		}
+	}
+	srcNode.shadows = shadows
+	sort.Sort(moved)
+
+	delete(srcNode.KeyNodes, name)
+	if moved.hasOwnKey() {
+		dstNode.included = false
+	}
+
+	// 按新的顺序重新分配目标section中key的序号
+	keyms := NewKeyNodesMapSorter(dstNode.KeyNodes)
+	keyms = append(keyms, dstNode.shadows...)
+	sort.Sort(keyms)
+
+	pos := len(keyms)
+	for i, n := range keyms {
+		if before != "" && f.formatKeyName(n.Name) == f.formatKeyName(before) {
+			pos = i
+			break
+		}
+	}
+
+	ordered := make(KeyNodesMapSorter, 0, len(keyms)+len(moved))
+	ordered = append(ordered, keyms[:pos]...)
+	ordered = append(ordered, moved...)
+	ordered = append(ordered, keyms[pos:]...)
+	for _, n := range ordered {
+		n.SeqNo = f.seqNoCounter
+		f.seqNoCounter++
+	}
+
+	dstNode.KeyNodes[name] = keyNode
+	for _, n := range moved {
+		if n != keyNode {
+			dstNode.shadows = append(dstNode.shadows, n)
+		}
+	}
+	return true
+}
+
+// renameIniKeyNode renames key node 'n', the original line keeps its format except the key name.
+func renameIniKeyNode(n *IniFileKeyNode, name string) {
+	n.Name = name
+	if n.raw == "" {
+		return
+	}
+
+	i := strings.IndexByte(n.raw, '=')
+	k := n.raw[:i]
+	n.raw = name + k[len(strings.TrimRight(k, " \t")):] + n.raw[i:]
+}
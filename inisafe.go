@@ -0,0 +1,164 @@
+package goblazer
+
+import (
+	"sync"
+)
+
+// IniWatchFunc is called with the old and new value of a watched key. A missing key has value "".
+type IniWatchFunc func(old string, new string)
+
+type iniWatcher struct {
+	id  uint64
+	sec string
+	key string
+	fn  IniWatchFunc
+}
+
+type iniChange struct {
+	fn       IniWatchFunc
+	old, new string
+}
+
+// SafeIniFile wraps an IniFile for concurrent use: any number of goroutines can read while SetString, Reload or
+// Merge changes it. Functions registered by Watch are called after a change, in the goroutine making the change.
+type SafeIniFile struct {
+	mu       sync.RWMutex
+	f        *IniFile
+	watchers []*iniWatcher // in registration order
+	watchID  uint64
+}
+
+// NewSafeIniFile creates a SafeIniFile owning 'f', 'f' must not be used directly any more.
+func NewSafeIniFile(f *IniFile) *SafeIniFile {
+	s := new(SafeIniFile)
+	s.f = f
+	return s
+}
+
+// Read calls 'fn' with the wrapped IniFile under read lock, 'fn' must not change it or keep it after return.
+func (s *SafeIniFile) Read(fn func(f *IniFile)) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	fn(s.f)
+}
+
+// Watch registers 'fn' to be called when the effective value of key 'key' in section 'sec' is changed by SetString,
+// Reload or Merge. It returns a function to cancel the registration.
+func (s *SafeIniFile) Watch(sec string, key string, fn IniWatchFunc) func() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.watchID++
+	id := s.watchID
+	s.watchers = append(s.watchers, &iniWatcher{id: id, sec: sec, key: key, fn: fn})
+
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		for i, w := range s.watchers {
+			if w.id == id {
+				s.watchers = append(s.watchers[:i:i], s.watchers[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// SetString :
+func (s *SafeIniFile) SetString(sec string, key string, val string) bool {
+	var ok bool
+	s.change(func() { ok = s.f.SetString(sec, key, val) })
+	return ok
+}
+
+// Reload replaces the wrapped IniFile by 'f', eg: a new IniFile loaded from disk.
+func (s *SafeIniFile) Reload(f *IniFile) {
+	s.change(func() { s.f = f })
+}
+
+// Merge merges 'o' into the wrapped IniFile, see IniFile.Merge.
+func (s *SafeIniFile) Merge(o *IniFile) {
+	s.change(func() { s.f.Merge(o) })
+}
+
+// GetString :
+func (s *SafeIniFile) GetString(sec string, key string, dflt string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.f.GetString(sec, key, dflt)
+}
+
+// GetInt :
+func (s *SafeIniFile) GetInt(sec string, key string, dflt int) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.f.GetInt(sec, key, dflt)
+}
+
+// GetInt32 :
+func (s *SafeIniFile) GetInt32(sec string, key string, dflt int32) int32 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.f.GetInt32(sec, key, dflt)
+}
+
+// GetInt64 :
+func (s *SafeIniFile) GetInt64(sec string, key string, dflt int64) int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.f.GetInt64(sec, key, dflt)
+}
+
+// GetFloat32 :
+func (s *SafeIniFile) GetFloat32(sec string, key string, dflt float32) float32 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.f.GetFloat32(sec, key, dflt)
+}
+
+// GetFloat64 :
+func (s *SafeIniFile) GetFloat64(sec string, key string, dflt float64) float64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.f.GetFloat64(sec, key, dflt)
+}
+
+// GetBool :
+func (s *SafeIniFile) GetBool(sec string, key string, dflt bool) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.f.GetBool(sec, key, dflt)
+}
+
+// GetStrings :
+func (s *SafeIniFile) GetStrings(sec string, key string, sep string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.f.GetStrings(sec, key, sep)
+}
+
+// change runs 'fn' under write lock and then calls watchers of changed keys.
+func (s *SafeIniFile) change(fn func()) {
+	s.mu.Lock()
+
+	olds := make([]string, len(s.watchers))
+	for i, w := range s.watchers {
+		olds[i], _ = s.f.getKeyValue(w.sec, w.key)
+	}
+
+	fn()
+
+	var changes []iniChange
+	for i, w := range s.watchers {
+		if v, _ := s.f.getKeyValue(w.sec, w.key); v != olds[i] {
+			changes = append(changes, iniChange{w.fn, olds[i], v})
+		}
+	}
+
+	s.mu.Unlock()
+
+	for _, c := range changes {
+		c.fn(c.old, c.new)
+	}
+}
@@ -0,0 +1,256 @@
+package goblazer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// IniValueType is the type of a key in IniSchema.
+type IniValueType int
+
+const (
+	// IniTypeString : any text
+	IniTypeString IniValueType = iota
+	// IniTypeInt : integer, see strconv.ParseInt
+	IniTypeInt
+	// IniTypeFloat : float, see strconv.ParseFloat
+	IniTypeFloat
+	// IniTypeBool : one of TrueStrings or FalseStrings
+	IniTypeBool
+	// IniTypeDuration : duration like "5m", see time.ParseDuration
+	IniTypeDuration
+	// IniTypeByteSize : byte size like "64KB", see ParseByteSize
+	IniTypeByteSize
+)
+
+var iniValueTypeNames = []string{"string", "int", "float", "bool", "duration", "bytesize"}
+
+func (t IniValueType) String() string {
+	if int(t) < len(iniValueTypeNames) {
+		return iniValueTypeNames[t]
+	}
+	return "IniValueType(" + strconv.Itoa(int(t)) + ")"
+}
+
+// IniKeySchema describes a key of section.
+type IniKeySchema struct {
+	Name     string       // key name
+	Type     IniValueType // value type
+	Required bool         // the key must exist if it has no default
+	Default  string       // default value, empty means no default
+	Min      string       // minimum value of numeric types in the same format as value, empty means no limit
+	Max      string       // maximum value of numeric types in the same format as value, empty means no limit
+	Allowed  []string     // allowed values, case-insensitive, empty means any value
+	Sep      string       // separator if the value is a list, each element is checked
+	Usage    string       // description of key
+}
+
+// IniSectionSchema describes a section.
+type IniSectionSchema struct {
+	Name     string         // section name without brackets, IniGlobalSection for global keys
+	Required bool           // the section must exist
+	Keys     []IniKeySchema // keys of section
+}
+
+// IniSchema describes sections and keys of an IniFile, see IniFile.Validate.
+type IniSchema struct {
+	Sections []IniSectionSchema
+	Strict   bool // sections and keys not in schema are violations
+}
+
+// IniSchemaError is a violation found by IniFile.Validate.
+type IniSchemaError struct {
+	Section string
+	Key     string
+	Reason  string
+}
+
+func (e *IniSchemaError) Error() string {
+	if e.Key == "" {
+		return fmt.Sprintf("ini: section [%s]: %s", e.Section, e.Reason)
+	}
+	return fmt.Sprintf("ini: section [%s] key %s: %s", e.Section, e.Key, e.Reason)
+}
+
+// IniSchemaErrors holds all violations of an IniFile.
+type IniSchemaErrors []*IniSchemaError
+
+func (es IniSchemaErrors) Error() string {
+	strs := make([]string, len(es))
+	for i, e := range es {
+		strs[i] = e.Error()
+	}
+	return strings.Join(strs, "\n")
+}
+
+// FindKey finds the schema of key 'key' in section 'sec', names are case-insensitive.
+func (s *IniSchema) FindKey(sec string, key string) (*IniKeySchema, bool) {
+	if secSchema, ok := s.findSection(sec); ok {
+		for i := range secSchema.Keys {
+			if strings.EqualFold(secSchema.Keys[i].Name, key) {
+				return &secSchema.Keys[i], true
+			}
+		}
+	}
+	return nil, false
+}
+
+func (s *IniSchema) findSection(sec string) (*IniSectionSchema, bool) {
+	sec = trimSectionName(sec)
+	for i := range s.Sections {
+		if strings.EqualFold(s.Sections[i].Name, sec) {
+			return &s.Sections[i], true
+		}
+	}
+	return nil, false
+}
+
+// Validate checks f against 'schema' and returns all violations as IniSchemaErrors, nil if f is valid. Keys with a
+// default value are not required to exist, but the default value itself is checked.
+func (f *IniFile) Validate(schema *IniSchema) error {
+	var es IniSchemaErrors
+
+	addError := func(sec string, key string, format string, a ...interface{}) {
+		es = append(es, &IniSchemaError{Section: sec, Key: key, Reason: fmt.Sprintf(format, a...)})
+	}
+
+	for _, secSchema := range schema.Sections {
+		if secSchema.Required && !f.IsSectionExisted(secSchema.Name) {
+			addError(secSchema.Name, "", "required section is missing")
+			continue
+		}
+
+		for _, keySchema := range secSchema.Keys {
+			val, ok := f.getKeyValue(secSchema.Name, keySchema.Name)
+			if !ok {
+				if keySchema.Default != "" {
+					val = keySchema.Default
+				} else if keySchema.Required {
+					addError(secSchema.Name, keySchema.Name, "required key is missing")
+					continue
+				} else {
+					continue
+				}
+			}
+
+			if err := keySchema.Check(val); err != nil {
+				addError(secSchema.Name, keySchema.Name, "%v", err)
+			}
+		}
+	}
+
+	if schema.Strict {
+		for _, sec := range f.Sections() {
+			if _, ok := schema.findSection(sec); !ok {
+				addError(sec, "", "unknown section")
+				continue
+			}
+
+			for _, key := range f.Keys(sec) {
+				if _, ok := schema.FindKey(sec, key); !ok {
+					addError(sec, key, "unknown key")
+				}
+			}
+		}
+	}
+
+	if len(es) == 0 {
+		return nil
+	}
+	return es
+}
+
+// Defaulted returns a copy of f with default values of 'schema' filled in for missing keys, their provenance is
+// "<default>".
+func (f *IniFile) Defaulted(schema *IniSchema) *IniFile {
+	g := MergeIniFiles(f)
+
+	for _, secSchema := range schema.Sections {
+		for _, keySchema := range secSchema.Keys {
+			if keySchema.Default == "" {
+				continue
+			}
+			if _, ok := g.getKeyValue(secSchema.Name, keySchema.Name); !ok {
+				g.setKeyValueFrom(secSchema.Name, keySchema.Name, keySchema.Default, "<default>", 0)
+			}
+		}
+	}
+	return g
+}
+
+// Check checks value 'val' against the type, range and allowed values of key.
+func (k *IniKeySchema) Check(val string) error {
+	vals := []string{val}
+	if k.Sep != "" {
+		vals = strings.Split(val, k.Sep)
+	}
+
+	for _, v := range vals {
+		if err := k.checkValue(strings.TrimSpace(v)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (k *IniKeySchema) checkValue(val string) error {
+	if len(k.Allowed) > 0 {
+		found := false
+		for _, a := range k.Allowed {
+			if strings.EqualFold(a, val) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("value %q is not one of %s", val, strings.Join(k.Allowed, ", "))
+		}
+	}
+
+	if k.Type == IniTypeString {
+		return nil
+	}
+	if k.Type == IniTypeBool {
+		if !IsTrueString(val) && !IsFalseString(val) {
+			return fmt.Errorf("value %q is not a bool", val)
+		}
+		return nil
+	}
+
+	n, err := parseIniNumber(k.Type, val)
+	if err != nil {
+		return fmt.Errorf("value %q is not a %s", val, k.Type)
+	}
+
+	if k.Min != "" {
+		if min, err := parseIniNumber(k.Type, k.Min); err == nil && n < min {
+			return fmt.Errorf("value %q is less than %s", val, k.Min)
+		}
+	}
+	if k.Max != "" {
+		if max, err := parseIniNumber(k.Type, k.Max); err == nil && n > max {
+			return fmt.Errorf("value %q is greater than %s", val, k.Max)
+		}
+	}
+	return nil
+}
+
+// parseIniNumber parses a value of numeric type 't' to float64 for range checks.
+func parseIniNumber(t IniValueType, s string) (float64, error) {
+	switch t {
+	case IniTypeInt:
+		n, err := strconv.ParseInt(s, 10, 64)
+		return float64(n), err
+	case IniTypeFloat:
+		return strconv.ParseFloat(s, 64)
+	case IniTypeDuration:
+		d, err := time.ParseDuration(s)
+		return float64(d), err
+	case IniTypeByteSize:
+		n, err := ParseByteSize(s)
+		return float64(n), err
+	}
+	return 0, fmt.Errorf("%s is not a numeric type", t)
+}
@@ -0,0 +1,169 @@
+package goblazer
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// IniSecretKeyEnv is the default env var holding the base64 encoded key of encrypted values.
+const IniSecretKeyEnv = "GOBLAZER_INI_KEY"
+
+// GenerateIniSecretKey returns a random AES-256 key encoded in base64, which can be saved in a key file or env var.
+func GenerateIniSecretKey() (string, error) {
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// LoadIniSecretKey reads a base64 encoded key from file 'path'.
+func LoadIniSecretKey(path string) ([]byte, error) {
+	buff, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return decodeIniSecretKey(string(buff))
+}
+
+// IniSecretKeyFromEnv reads a base64 encoded key from env var 'name', IniSecretKeyEnv if 'name' is empty.
+func IniSecretKeyFromEnv(name string) ([]byte, error) {
+	if name == "" {
+		name = IniSecretKeyEnv
+	}
+
+	s, ok := os.LookupEnv(name)
+	if !ok {
+		return nil, fmt.Errorf("ini: env var %s is not set", name)
+	}
+	return decodeIniSecretKey(s)
+}
+
+// EncryptIniValue encrypts 'plain' with AES-GCM and returns it as "ENC(base64 of nonce and ciphertext)".
+func EncryptIniValue(key []byte, plain string) (string, error) {
+	gcm, err := newIniSecretCipher(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	buff := gcm.Seal(nonce, nonce, []byte(plain), nil)
+	return "ENC(" + base64.StdEncoding.EncodeToString(buff) + ")", nil
+}
+
+// DecryptIniValue decrypts a value returned by EncryptIniValue.
+func DecryptIniValue(key []byte, val string) (string, error) {
+	if !isIniSecretValue(val) {
+		return "", errors.New("ini: value is not in format ENC(...)")
+	}
+
+	gcm, err := newIniSecretCipher(key)
+	if err != nil {
+		return "", err
+	}
+
+	buff, err := base64.StdEncoding.DecodeString(val[4 : len(val)-1])
+	if err != nil {
+		return "", err
+	}
+	if len(buff) < gcm.NonceSize() {
+		return "", errors.New("ini: encrypted value is too short")
+	}
+
+	plain, err := gcm.Open(nil, buff[:gcm.NonceSize()], buff[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+// SetSecret encrypts 'val' with IniFileOptions.SecretKey and sets it as an ENC(...) value.
+func (f *IniFile) SetSecret(sec string, key string, val string) bool {
+	s, err := EncryptIniValue(f.options.SecretKey, val)
+	if err != nil {
+		return false
+	}
+	return f.setKeyValue(sec, key, s)
+}
+
+// GetSecret gets the plaintext of an ENC(...) value. Unlike GetString, it fails if the key does not exist or the
+// value can not be decrypted, so that a server never uses a ciphertext as password. A value not in ENC(...) format
+// is returned as it is.
+func (f *IniFile) GetSecret(sec string, key string) (string, error) {
+	s, ok := f.getRawKeyValue(sec, key)
+	if !ok {
+		return "", fmt.Errorf("ini: section [%s] key %s does not exist", trimSectionName(sec), key)
+	}
+	if !isIniSecretValue(s) {
+		return s, nil
+	}
+
+	if f.options.SecretKey == nil {
+		return "", fmt.Errorf("ini: section [%s] key %s: secret key is not set", trimSectionName(sec), key)
+	}
+
+	plain, err := DecryptIniValue(f.options.SecretKey, s)
+	if err != nil {
+		return "", fmt.Errorf("ini: section [%s] key %s: %v", trimSectionName(sec), key, err)
+	}
+	return plain, nil
+}
+
+// SetSecretKey sets the key to decrypt ENC(...) values, see IniFileOptions.SecretKey.
+func (f *IniFile) SetSecretKey(key []byte) {
+	f.options.SecretKey = key
+}
+
+// iniMaskSecretsID marks a resolving for display in 'visiting' of resolveKeyValue, see getDisplayKeyValue.
+const iniMaskSecretsID = "\x00\x00"
+
+// getDisplayKeyValue returns the effective value of key for help and dumps. ENC(...) values, also those referred
+// by other values, are shown as they are stored and never in plaintext.
+func (f *IniFile) getDisplayKeyValue(sec string, key string) (string, bool) {
+	return f.resolveKeyValue(sec, key, map[string]bool{iniMaskSecretsID: true})
+}
+
+// decryptKeyValue returns the plaintext of an ENC(...) value. A value which can not be decrypted is returned as it
+// is, use GetSecret to get the error.
+func (f *IniFile) decryptKeyValue(val string) string {
+	if f.options.SecretKey == nil || !isIniSecretValue(val) {
+		return val
+	}
+
+	if s, err := DecryptIniValue(f.options.SecretKey, val); err == nil {
+		return s
+	}
+	return val
+}
+
+func isIniSecretValue(val string) bool {
+	return strings.HasPrefix(val, "ENC(") && strings.HasSuffix(val, ")")
+}
+
+func decodeIniSecretKey(s string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("ini: secret key is not base64: %v", err)
+	}
+	return key, nil
+}
+
+func newIniSecretCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
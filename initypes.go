@@ -0,0 +1,253 @@
+package goblazer
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// IniTimeLayout is the default layout of GetTime and SetTime.
+const IniTimeLayout = "2006-01-02 15:04:05"
+
+var byteSizeUnits = []struct {
+	name string
+	size int64
+}{
+	{"TB", 1 << 40}, {"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10},
+	{"TIB", 1 << 40}, {"GIB", 1 << 30}, {"MIB", 1 << 20}, {"KIB", 1 << 10},
+	{"T", 1 << 40}, {"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10}, {"B", 1},
+}
+
+// ParseByteSize parses a byte size like "64KB", "1.5MB" or "512". Units are binary, 1KB = 1KiB = 1024 bytes.
+func ParseByteSize(s string) (int64, error) {
+	str := strings.ToUpper(strings.TrimSpace(s))
+
+	unit := int64(1)
+	for _, u := range byteSizeUnits {
+		if strings.HasSuffix(str, u.name) {
+			str = strings.TrimSpace(str[:len(str)-len(u.name)])
+			unit = u.size
+			break
+		}
+	}
+
+	if n, err := strconv.ParseInt(str, 10, 64); err == nil {
+		if n > math.MaxInt64/unit || n < math.MinInt64/unit {
+			return 0, fmt.Errorf("byte size %q overflows int64", s)
+		}
+		return n * unit, nil
+	}
+
+	v, err := strconv.ParseFloat(str, 64)
+	if err != nil || math.IsNaN(v) {
+		return 0, fmt.Errorf("invalid byte size %q", s)
+	}
+
+	// float64(math.MaxInt64)等于2^63，已经溢出
+	if v *= float64(unit); v >= math.MaxInt64 || v < math.MinInt64 {
+		return 0, fmt.Errorf("byte size %q overflows int64", s)
+	}
+	return int64(v), nil
+}
+
+// FormatByteSize formats 'n' with the largest unit dividing it exactly, eg: 65536 is "64KB".
+func FormatByteSize(n int64) string {
+	for _, u := range byteSizeUnits[:4] {
+		if n != 0 && n%u.size == 0 {
+			return strconv.FormatInt(n/u.size, 10) + u.name
+		}
+	}
+	return strconv.FormatInt(n, 10)
+}
+
+// GetDuration gets a duration like "5m" or "1h30m", see time.ParseDuration.
+func (f *IniFile) GetDuration(sec string, key string, dflt time.Duration) time.Duration {
+	if s, ok := f.getKeyValue(sec, key); ok {
+		if v, err := time.ParseDuration(s); err == nil {
+			return v
+		}
+	}
+	return dflt
+}
+
+// SetDuration :
+func (f *IniFile) SetDuration(sec string, key string, val time.Duration) bool {
+	return f.setKeyValue(sec, key, val.String())
+}
+
+// GetDurations :
+func (f *IniFile) GetDurations(sec string, key string, sep string) []time.Duration {
+	var ret []time.Duration
+	if s, ok := f.getKeyValue(sec, key); ok {
+		strs := strings.Split(s, sep)
+		ret = make([]time.Duration, len(strs))
+		for i, v := range strs {
+			ret[i], _ = time.ParseDuration(strings.TrimSpace(v))
+		}
+	}
+	return ret
+}
+
+// SetDurations :
+func (f *IniFile) SetDurations(sec string, key string, val []time.Duration, sep string) bool {
+	strs := make([]string, len(val))
+	for i, v := range val {
+		strs[i] = v.String()
+	}
+	return f.setKeyValue(sec, key, strings.Join(strs, sep))
+}
+
+// GetByteSize gets a byte size like "64KB", see ParseByteSize.
+func (f *IniFile) GetByteSize(sec string, key string, dflt int64) int64 {
+	if s, ok := f.getKeyValue(sec, key); ok {
+		if v, err := ParseByteSize(s); err == nil {
+			return v
+		}
+	}
+	return dflt
+}
+
+// SetByteSize :
+func (f *IniFile) SetByteSize(sec string, key string, val int64) bool {
+	return f.setKeyValue(sec, key, FormatByteSize(val))
+}
+
+// GetByteSizes :
+func (f *IniFile) GetByteSizes(sec string, key string, sep string) []int64 {
+	var ret []int64
+	if s, ok := f.getKeyValue(sec, key); ok {
+		strs := strings.Split(s, sep)
+		ret = make([]int64, len(strs))
+		for i, v := range strs {
+			ret[i], _ = ParseByteSize(v)
+		}
+	}
+	return ret
+}
+
+// SetByteSizes :
+func (f *IniFile) SetByteSizes(sec string, key string, val []int64, sep string) bool {
+	strs := make([]string, len(val))
+	for i, v := range val {
+		strs[i] = FormatByteSize(v)
+	}
+	return f.setKeyValue(sec, key, strings.Join(strs, sep))
+}
+
+// GetTime gets a time in 'layout', IniTimeLayout if empty, and in location 'loc', time.Local if nil.
+func (f *IniFile) GetTime(sec string, key string, layout string, loc *time.Location, dflt time.Time) time.Time {
+	if s, ok := f.getKeyValue(sec, key); ok {
+		if v, err := parseIniTime(s, layout, loc); err == nil {
+			return v
+		}
+	}
+	return dflt
+}
+
+// SetTime sets a time in 'layout', IniTimeLayout if empty.
+func (f *IniFile) SetTime(sec string, key string, val time.Time, layout string) bool {
+	if layout == "" {
+		layout = IniTimeLayout
+	}
+	return f.setKeyValue(sec, key, val.Format(layout))
+}
+
+// GetTimes :
+func (f *IniFile) GetTimes(sec string, key string, sep string, layout string, loc *time.Location) []time.Time {
+	var ret []time.Time
+	if s, ok := f.getKeyValue(sec, key); ok {
+		strs := strings.Split(s, sep)
+		ret = make([]time.Time, len(strs))
+		for i, v := range strs {
+			ret[i], _ = parseIniTime(v, layout, loc)
+		}
+	}
+	return ret
+}
+
+// SetTimes :
+func (f *IniFile) SetTimes(sec string, key string, val []time.Time, sep string, layout string) bool {
+	if layout == "" {
+		layout = IniTimeLayout
+	}
+
+	strs := make([]string, len(val))
+	for i, v := range val {
+		strs[i] = v.Format(layout)
+	}
+	return f.setKeyValue(sec, key, strings.Join(strs, sep))
+}
+
+// GetEnum gets the id of an enum name like "warn" of the registered enum type 'enumType', see RegisterTabEnum.
+// Names are case-insensitive.
+func (f *IniFile) GetEnum(sec string, key string, enumType string, dflt int) int {
+	if s, ok := f.getKeyValue(sec, key); ok {
+		if v, ok := findIniEnumID(enumType, s); ok {
+			return v
+		}
+	}
+	return dflt
+}
+
+// SetEnum sets the name of enum id 'val'. It returns false if 'val' is not defined by 'enumType'.
+func (f *IniFile) SetEnum(sec string, key string, enumType string, val int) bool {
+	s, ok := findIniEnumName(enumType, val)
+	if !ok {
+		return false
+	}
+	return f.setKeyValue(sec, key, s)
+}
+
+// GetEnums gets ids of enum names, unknown names are 'dflt'.
+func (f *IniFile) GetEnums(sec string, key string, sep string, enumType string, dflt int) []int {
+	var ret []int
+	if s, ok := f.getKeyValue(sec, key); ok {
+		strs := strings.Split(s, sep)
+		ret = make([]int, len(strs))
+		for i, v := range strs {
+			if ret[i], ok = findIniEnumID(enumType, v); !ok {
+				ret[i] = dflt
+			}
+		}
+	}
+	return ret
+}
+
+// SetEnums sets names of enum ids. It returns false if any id is not defined by 'enumType'.
+func (f *IniFile) SetEnums(sec string, key string, enumType string, val []int, sep string) bool {
+	strs := make([]string, len(val))
+	for i, v := range val {
+		s, ok := findIniEnumName(enumType, v)
+		if !ok {
+			return false
+		}
+		strs[i] = s
+	}
+	return f.setKeyValue(sec, key, strings.Join(strs, sep))
+}
+
+func parseIniTime(s string, layout string, loc *time.Location) (time.Time, error) {
+	if layout == "" {
+		layout = IniTimeLayout
+	}
+	if loc == nil {
+		loc = time.Local
+	}
+	return time.ParseInLocation(layout, strings.TrimSpace(s), loc)
+}
+
+func findIniEnumID(enumType string, name string) (int, bool) {
+	if e := FindTabEnum(enumType); e != nil {
+		return e.ID(strings.TrimSpace(name))
+	}
+	return 0, false
+}
+
+func findIniEnumName(enumType string, id int) (string, bool) {
+	if e := FindTabEnum(enumType); e != nil {
+		return e.NameOf(id)
+	}
+	return "", false
+}
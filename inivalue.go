@@ -0,0 +1,110 @@
+package goblazer
+
+import (
+	"strconv"
+	"strings"
+)
+
+// parseValue parses value 'val' of a key-value line:
+//
+//	key = "quoted \"value\"\n"  - a quoted value with Go escape sequences
+//	key = first, \              - a value ending with '\' is continued by next line if
+//	      second                  IniFileOptions.LineContinuation is set
+//	key = <<EOF                 - a heredoc value ends at line "EOF", line breaks are kept
+//	line 1
+//	line 2
+//	EOF
+//
+// Extra lines read are appended to 'lines'. If the value is malformed, it returns the value as it is with a reason.
+func (f *IniFile) parseValue(buff []byte, size int64, val string, lines *[]string) (string, string) {
+	if strings.HasPrefix(val, "\"") {
+		return parseQuotedIniValue(val)
+	}
+
+	if strings.HasPrefix(val, "<<") && isIniHeredocTag(val[2:]) {
+		var strs []string
+		for {
+			line, ok := f.nextLine(buff, size)
+			if !ok {
+				return strings.Join(strs, "\n"), "unterminated heredoc value"
+			}
+
+			*lines = append(*lines, line)
+			if strings.TrimSpace(line) == val[2:] {
+				return strings.Join(strs, "\n"), ""
+			}
+			strs = append(strs, line)
+		}
+	}
+
+	for f.options.LineContinuation && strings.HasSuffix(val, "\\") { // 续行
+		line, ok := f.nextLine(buff, size)
+		if !ok {
+			return val[:len(val)-1], ""
+		}
+
+		*lines = append(*lines, line)
+		val = val[:len(val)-1] + strings.TrimSpace(line)
+	}
+
+	return val, ""
+}
+
+// nextLine reads next line of buffer.
+func (f *IniFile) nextLine(buff []byte, size int64) (string, bool) {
+	start := f.offset
+	n := f.readLine(buff, size)
+	if n < 0 {
+		return "", false
+	}
+	return string(buff[start : start+n]), true
+}
+
+func parseQuotedIniValue(val string) (string, string) {
+	end := -1
+	for i := 1; i < len(val); i++ {
+		if val[i] == '\\' {
+			i++
+		} else if val[i] == '"' {
+			end = i
+			break
+		}
+	}
+
+	if end < 0 {
+		return val, "unterminated quoted value"
+	}
+
+	if rest := strings.TrimSpace(val[end+1:]); rest != "" && rest[0] != ';' && rest[0] != '#' {
+		return val, "unexpected text after quoted value"
+	}
+
+	s, err := strconv.Unquote(val[:end+1])
+	if err != nil {
+		return val, "invalid escape sequence in quoted value"
+	}
+	return s, ""
+}
+
+func isIniHeredocTag(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for i, r := range s {
+		if !(r == '_' || (r >= 'A' && r <= 'Z') || (r >= 'a' && r <= 'z') || (i > 0 && r >= '0' && r <= '9')) {
+			return false
+		}
+	}
+	return true
+}
+
+// quoteIniValue quotes 's' if it can not be written as it is: with leading or trailing spaces, line breaks, or
+// beginning like a quoted, continued or heredoc value.
+func quoteIniValue(s string) string {
+	if s != strings.TrimSpace(s) || strings.ContainsAny(s, "\r\n") || strings.HasPrefix(s, "\"") ||
+		strings.HasSuffix(s, "\\") || strings.HasPrefix(s, "<<") {
+		return strconv.Quote(s)
+	}
+	return s
+}
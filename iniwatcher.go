@@ -0,0 +1,163 @@
+package goblazer
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+type iniFileStamp struct {
+	modTime time.Time
+	size    int64
+	sum     [sha256.Size]byte // 修改时间精度不够时，同样大小的改动靠内容哈希发现
+}
+
+// IniWatcher polls the files an IniFile was loaded from, including included files, and reloads them on change
+// with the same encoding and options. A file is changed if its modification time, size or SHA-256 of content is
+// changed. A reloaded IniFile is published to Config() only if Validate accepts it,
+// otherwise the old one is kept and the error is passed to OnError.
+type IniWatcher struct {
+	Validate func(f *IniFile) error // optional validation hook, called before a reloaded IniFile is published
+	OnError  func(err error)        // optional, called when a reload fails
+
+	config  *SafeIniFile
+	path    string
+	code    string
+	options IniFileOptions
+	stamps  map[string]iniFileStamp
+	mu      sync.Mutex    // serializes Check
+	stop    chan struct{} // closed by Stop, nil if not started
+	stopMu  sync.Mutex    // guards stop
+}
+
+// NewIniWatcher creates an IniWatcher for 'f', which must be loaded from file. 'f' is owned by the watcher and
+// must only be used through Config() later.
+func NewIniWatcher(f *IniFile) (*IniWatcher, error) {
+	if len(f.files) == 0 {
+		return nil, errors.New("ini watcher: IniFile is not loaded from file")
+	}
+
+	w := new(IniWatcher)
+	w.config = NewSafeIniFile(f)
+	w.path = f.files[0]
+	w.code = f.code
+	w.options = f.options
+	w.stamps = statIniFiles(f.files)
+	return w, nil
+}
+
+// Config returns the published config, use its Watch to subscribe changes of keys.
+func (w *IniWatcher) Config() *SafeIniFile {
+	return w.config
+}
+
+// Start checks files every 'interval' in a new goroutine until Stop is called. A stopped watcher can be started
+// again. It fails if 'interval' is not positive or the watcher is already started.
+func (w *IniWatcher) Start(interval time.Duration) error {
+	if interval <= 0 {
+		return fmt.Errorf("ini watcher: invalid interval %v", interval)
+	}
+
+	w.stopMu.Lock()
+	defer w.stopMu.Unlock()
+
+	if w.stop != nil {
+		return errors.New("ini watcher: already started")
+	}
+
+	stop := make(chan struct{})
+	w.stop = stop
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				w.Check()
+			case <-stop:
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop stops the goroutine started by Start, it does nothing if the watcher is not started.
+func (w *IniWatcher) Stop() {
+	w.stopMu.Lock()
+	defer w.stopMu.Unlock()
+
+	if w.stop != nil {
+		close(w.stop)
+		w.stop = nil
+	}
+}
+
+// Check reloads files immediately if any of them is changed. It returns true if a new config is published. A
+// failed reload is not retried until files are changed again.
+func (w *IniWatcher) Check() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var files []string
+	for file := range w.stamps {
+		files = append(files, file)
+	}
+
+	stamps := statIniFiles(files)
+	if equalIniFileStamps(stamps, w.stamps) {
+		return false
+	}
+
+	f := NewIniFileWithOptions(w.options)
+	err := f.LoadWithError(w.path, w.code)
+	if err == nil && w.Validate != nil {
+		err = w.Validate(f)
+	}
+
+	if err != nil {
+		w.stamps = stamps
+		if w.OnError != nil {
+			w.OnError(err)
+		}
+		return false
+	}
+
+	w.stamps = statIniFiles(f.files) // include的文件可能有变化
+	w.config.Reload(f)
+	return true
+}
+
+func statIniFiles(files []string) map[string]iniFileStamp {
+	stamps := make(map[string]iniFileStamp, len(files))
+	for _, file := range files {
+		var st iniFileStamp
+		if fi, err := os.Stat(file); err == nil {
+			st = iniFileStamp{modTime: fi.ModTime(), size: fi.Size()}
+			if buff, err := ioutil.ReadFile(file); err == nil {
+				st.sum = sha256.Sum256(buff)
+			}
+		}
+		stamps[file] = st
+	}
+	return stamps
+}
+
+func equalIniFileStamps(a map[string]iniFileStamp, b map[string]iniFileStamp) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for k, v := range a {
+		if u, ok := b[k]; !ok || !u.modTime.Equal(v.modTime) || u.size != v.size || u.sum != v.sum {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,248 @@
+package goblazer
+
+import (
+	"math"
+	"strconv"
+)
+
+type tabColumnKey struct {
+	col  int    // index of column
+	kind string // type of cached values, eg: "int32"
+}
+
+// TabInt32Column holds all values of a tab file column parsed as int32. The header row(row 0) is excluded, so
+// element i is the value of row i+1. Cells which can not be parsed are 0.
+type TabInt32Column []int32
+
+// Min returns the minimum value of column, or 0 if column is empty.
+func (c TabInt32Column) Min() int32 {
+	if len(c) == 0 {
+		return 0
+	}
+
+	ret := c[0]
+	for _, v := range c[1:] {
+		if v < ret {
+			ret = v
+		}
+	}
+	return ret
+}
+
+// Max returns the maximum value of column, or 0 if column is empty.
+func (c TabInt32Column) Max() int32 {
+	if len(c) == 0 {
+		return 0
+	}
+
+	ret := c[0]
+	for _, v := range c[1:] {
+		if v > ret {
+			ret = v
+		}
+	}
+	return ret
+}
+
+// Sum returns the sum of all values of column.
+func (c TabInt32Column) Sum() int64 {
+	var ret int64
+	for _, v := range c {
+		ret += int64(v)
+	}
+	return ret
+}
+
+// Histogram returns the number of times each value appears in column.
+func (c TabInt32Column) Histogram() map[int32]int {
+	ret := make(map[int32]int)
+	for _, v := range c {
+		ret[v]++
+	}
+	return ret
+}
+
+// TabFloat64Column holds all values of a tab file column parsed as float64. The header row(row 0) is excluded, so
+// element i is the value of row i+1. Cells which can not be parsed are 0.
+type TabFloat64Column []float64
+
+// Min returns the minimum value of column, or 0 if column is empty.
+func (c TabFloat64Column) Min() float64 {
+	if len(c) == 0 {
+		return 0
+	}
+
+	ret := c[0]
+	for _, v := range c[1:] {
+		ret = math.Min(ret, v)
+	}
+	return ret
+}
+
+// Max returns the maximum value of column, or 0 if column is empty.
+func (c TabFloat64Column) Max() float64 {
+	if len(c) == 0 {
+		return 0
+	}
+
+	ret := c[0]
+	for _, v := range c[1:] {
+		ret = math.Max(ret, v)
+	}
+	return ret
+}
+
+// Sum returns the sum of all values of column.
+func (c TabFloat64Column) Sum() float64 {
+	var ret float64
+	for _, v := range c {
+		ret += v
+	}
+	return ret
+}
+
+// Histogram splits [min, max] of finite values into 'buckets' intervals of the same width and returns the number
+// of values falling into each interval. Inf and NaN values are skipped.
+func (c TabFloat64Column) Histogram(buckets int) []int {
+	if buckets <= 0 {
+		return nil
+	}
+
+	ret := make([]int, buckets)
+	min, max := math.Inf(1), math.Inf(-1)
+	for _, v := range c {
+		if !math.IsInf(v, 0) && !math.IsNaN(v) {
+			min, max = math.Min(min, v), math.Max(max, v)
+		}
+	}
+	if min > max { // 没有有限值
+		return ret
+	}
+
+	width := (max - min) / float64(buckets)
+	for _, v := range c {
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			continue
+		}
+
+		idx := 0
+		if width > 0 {
+			idx = int((v - min) / width)
+		}
+		if idx >= buckets { // v == max
+			idx = buckets - 1
+		}
+		ret[idx]++
+	}
+	return ret
+}
+
+// TabStringColumn holds all values of a tab file column. The header row(row 0) is excluded, so element i is the
+// value of row i+1.
+type TabStringColumn []string
+
+// Histogram returns the number of times each value appears in column.
+func (c TabStringColumn) Histogram() map[string]int {
+	ret := make(map[string]int)
+	for _, v := range c {
+		ret[v]++
+	}
+	return ret
+}
+
+// Int32Column parses column 'col' as int32 values. The result is cached until the column is changed by SetCell,
+// so the caller must not modify it. It returns nil if 'col' is out of range.
+func (f *TabFile) Int32Column(col int) TabInt32Column {
+	if v, ok := f.getColumnCache(col, "int32"); ok {
+		return v.(TabInt32Column)
+	}
+
+	if col < 0 || col >= f.cols {
+		return nil
+	}
+
+	ret := make(TabInt32Column, 0, Max(f.rows-1, 0))
+	for i := 1; i < f.rows; i++ {
+		v, _ := strconv.ParseInt(f.tabs[i*f.cols+col].content, 10, 32)
+		ret = append(ret, int32(v))
+	}
+
+	f.setColumnCache(col, "int32", ret)
+	return ret
+}
+
+// Float64Column parses column 'col' as float64 values. The result is cached until the column is changed by SetCell,
+// so the caller must not modify it. It returns nil if 'col' is out of range.
+func (f *TabFile) Float64Column(col int) TabFloat64Column {
+	if v, ok := f.getColumnCache(col, "float64"); ok {
+		return v.(TabFloat64Column)
+	}
+
+	if col < 0 || col >= f.cols {
+		return nil
+	}
+
+	ret := make(TabFloat64Column, 0, Max(f.rows-1, 0))
+	for i := 1; i < f.rows; i++ {
+		v, _ := strconv.ParseFloat(f.tabs[i*f.cols+col].content, 64)
+		ret = append(ret, v)
+	}
+
+	f.setColumnCache(col, "float64", ret)
+	return ret
+}
+
+// StringColumn returns all values of column 'col'. The result is cached until the column is changed by SetCell,
+// so the caller must not modify it. It returns nil if 'col' is out of range.
+func (f *TabFile) StringColumn(col int) TabStringColumn {
+	if v, ok := f.getColumnCache(col, "string"); ok {
+		return v.(TabStringColumn)
+	}
+
+	if col < 0 || col >= f.cols {
+		return nil
+	}
+
+	ret := make(TabStringColumn, 0, Max(f.rows-1, 0))
+	for i := 1; i < f.rows; i++ {
+		ret = append(ret, f.tabs[i*f.cols+col].content)
+	}
+
+	f.setColumnCache(col, "string", ret)
+	return ret
+}
+
+func (f *TabFile) getColumnCache(col int, kind string) (interface{}, bool) {
+	f.columnsMu.Lock()
+	defer f.columnsMu.Unlock()
+
+	v, ok := f.columns[tabColumnKey{col, kind}]
+	return v, ok
+}
+
+func (f *TabFile) setColumnCache(col int, kind string, v interface{}) {
+	f.columnsMu.Lock()
+	defer f.columnsMu.Unlock()
+
+	if f.columns == nil {
+		f.columns = make(map[tabColumnKey]interface{})
+	}
+	f.columns[tabColumnKey{col, kind}] = v
+}
+
+func (f *TabFile) invalidateColumnCache(col int) {
+	f.columnsMu.Lock()
+	defer f.columnsMu.Unlock()
+
+	for k := range f.columns {
+		if k.col == col {
+			delete(f.columns, k)
+		}
+	}
+}
+
+func (f *TabFile) clearColumnCache() {
+	f.columnsMu.Lock()
+	f.columns = nil
+	f.columnsMu.Unlock()
+}
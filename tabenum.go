@@ -0,0 +1,214 @@
+package goblazer
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// TabEnum maps the readable names of an enum type, eg: "Epic" of "Quality", to ids. Names are case-insensitive.
+type TabEnum struct {
+	Name  string         // name of enum type
+	ids   map[string]int // lower case name -> id
+	names map[int]string // id -> name
+}
+
+// NewTabEnum creates a new enum type named 'name' with the name-id pairs in 'm'.
+func NewTabEnum(name string, m map[string]int) *TabEnum {
+	e := new(TabEnum)
+	e.Name = name
+	e.ids = make(map[string]int, len(m))
+	e.names = make(map[int]string, len(m))
+
+	for k, v := range m {
+		e.Add(k, v)
+	}
+	return e
+}
+
+// NewTabEnumFromTab creates a new enum type named 'name' from tab file 't'. Every row except the header gives a
+// name in column 'nameCol' and an id in column 'idCol'.
+func NewTabEnumFromTab(name string, t *TabFile, nameCol string, idCol string) (*TabEnum, error) {
+	nc, ic := t.FindCol(nameCol), t.FindCol(idCol)
+	if nc < 0 || ic < 0 {
+		return nil, fmt.Errorf("enum %s: column %s or %s not found", name, nameCol, idCol)
+	}
+
+	e := NewTabEnum(name, nil)
+	for i := 1; i < t.GetRows(); i++ {
+		s := t.GetStrByIntIdx(i, nc, "")
+		if s == "" {
+			continue
+		}
+		e.Add(s, t.GetIntByIntIdx(i, ic, 0))
+	}
+	return e, nil
+}
+
+// Add adds a name-id pair to enum. The first name added for an id is used when converting the id back to name.
+func (e *TabEnum) Add(name string, id int) {
+	e.ids[strings.ToLower(name)] = id
+	if _, ok := e.names[id]; !ok {
+		e.names[id] = name
+	}
+}
+
+// ID returns the id of 'name'.
+func (e *TabEnum) ID(name string) (int, bool) {
+	id, ok := e.ids[strings.ToLower(name)]
+	return id, ok
+}
+
+// NameOf returns the name of 'id'.
+func (e *TabEnum) NameOf(id int) (string, bool) {
+	name, ok := e.names[id]
+	return name, ok
+}
+
+var tabEnums = struct {
+	sync.RWMutex
+	m map[string]*TabEnum
+}{m: make(map[string]*TabEnum)}
+
+// RegisterTabEnum registers 'e' with its name, so that tab files can refer to it. A registered enum type must not
+// be modified any more.
+func RegisterTabEnum(e *TabEnum) {
+	tabEnums.Lock()
+	tabEnums.m[strings.ToLower(e.Name)] = e
+	tabEnums.Unlock()
+}
+
+// FindTabEnum returns the registered enum type named 'name', or nil if not existed.
+func FindTabEnum(name string) *TabEnum {
+	tabEnums.RLock()
+	defer tabEnums.RUnlock()
+	return tabEnums.m[strings.ToLower(name)]
+}
+
+type tabEnumColumn struct {
+	ids []int  // converted ids, element i belongs to row i+1
+	oks []bool // false if the cell is empty or an unknown name
+}
+
+// BindEnum declares that column 'col' holds names of the registered enum type 'enumType'. The column is converted
+// immediately and again on every Load, and an error lists all unknown names. Load returns false if a bound column
+// contains unknown names, but keeps the loaded data, see LoadWithError. Empty cells are allowed.
+func (f *TabFile) BindEnum(col string, enumType string) error {
+	if f.enumBinds == nil {
+		f.enumBinds = make(map[string]string)
+	}
+	f.enumBinds[strings.ToLower(col)] = enumType
+	return f.checkEnumColumn(col, enumType)
+}
+
+// GetEnumByIntIdx returns the id of the enum name in cell, or 'dflt' if the cell is empty or not a known name.
+func (f *TabFile) GetEnumByIntIdx(row int, col int, enumType string, dflt int) int {
+	c := f.enumColumn(col, enumType)
+	if c == nil || row < 1 || row >= f.rows || !c.oks[row-1] {
+		return dflt
+	}
+	return c.ids[row-1]
+}
+
+// GetEnumByStrIdx is
+func (f *TabFile) GetEnumByStrIdx(row string, col string, enumType string, dflt int) int {
+	return f.GetEnumByIntIdx(f.FindRow(row), f.FindCol(col), enumType, dflt)
+}
+
+// GetEnumByMixIdx is
+func (f *TabFile) GetEnumByMixIdx(row int, col string, enumType string, dflt int) int {
+	return f.GetEnumByIntIdx(row, f.FindCol(col), enumType, dflt)
+}
+
+// SetEnumByIntIdx writes the name of 'id' into cell, so that Save keeps the readable name. It returns false if
+// 'id' is not defined by the enum type.
+func (f *TabFile) SetEnumByIntIdx(row int, col int, enumType string, id int) bool {
+	e := FindTabEnum(enumType)
+	if e == nil {
+		return false
+	}
+
+	name, ok := e.NameOf(id)
+	if !ok {
+		return false
+	}
+	return f.SetCell(row, col, name)
+}
+
+// SetEnumByStrIdx is
+func (f *TabFile) SetEnumByStrIdx(row string, col string, enumType string, id int) bool {
+	return f.SetEnumByIntIdx(f.FindRow(row), f.FindCol(col), enumType, id)
+}
+
+// SetEnumByMixIdx is
+func (f *TabFile) SetEnumByMixIdx(row int, col string, enumType string, id int) bool {
+	return f.SetEnumByIntIdx(row, f.FindCol(col), enumType, id)
+}
+
+func (f *TabFile) enumColumn(col int, enumType string) *tabEnumColumn {
+	kind := "enum:" + strings.ToLower(enumType)
+	if v, ok := f.getColumnCache(col, kind); ok {
+		return v.(*tabEnumColumn)
+	}
+
+	e := FindTabEnum(enumType)
+	if e == nil || col < 0 || col >= f.cols {
+		return nil
+	}
+
+	n := Max(f.rows-1, 0)
+	c := &tabEnumColumn{ids: make([]int, n), oks: make([]bool, n)}
+	for i := 1; i < f.rows; i++ {
+		c.ids[i-1], c.oks[i-1] = e.ID(f.tabs[i*f.cols+col].content)
+	}
+
+	f.setColumnCache(col, kind, c)
+	return c
+}
+
+func (f *TabFile) checkEnumColumn(col string, enumType string) error {
+	if FindTabEnum(enumType) == nil {
+		return fmt.Errorf("enum type %s not registered", enumType)
+	}
+
+	idx := f.FindCol(col)
+	if idx < 0 {
+		return nil // 空表或者没有这一列
+	}
+
+	var unknown []string
+	c := f.enumColumn(idx, enumType)
+	for i, ok := range c.oks {
+		if s := f.tabs[(i+1)*f.cols+idx].content; !ok && s != "" {
+			unknown = append(unknown, fmt.Sprintf("row %d: %s", i+1, s))
+		}
+	}
+
+	if len(unknown) > 0 {
+		return fmt.Errorf("column %s has unknown %s names: %s", col, enumType, strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+func (f *TabFile) checkEnumBinds() error {
+	var errs []string
+
+	cols := make([]string, 0, len(f.enumBinds))
+	for col := range f.enumBinds {
+		cols = append(cols, col)
+	}
+	sort.Strings(cols)
+
+	for _, col := range cols {
+		if err := f.checkEnumColumn(col, f.enumBinds[col]); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
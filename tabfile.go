@@ -6,6 +6,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 
 	"fmt"
 )
@@ -26,10 +27,16 @@ type tabOffset struct {
 }
 
 // TabFile opens a "xxx.tab" file to resolve it.
+// TabFile is not safe for concurrent use: Load, Reset, BindEnum and all Set* methods must not run while another
+// goroutine reads the same TabFile. Get* and *Column methods may run concurrently, their column cache is locked.
+// Use Freeze to get a TabSnapshot for sharing between goroutines.
 type TabFile struct {
-	rows int
-	cols int
-	tabs []*tabCell
+	rows      int
+	cols      int
+	tabs      []*tabCell
+	columns   map[tabColumnKey]interface{} // cached typed columns, see Int32Column
+	columnsMu sync.Mutex                   // guards columns
+	enumBinds map[string]string            // lower case column name -> enum type, see BindEnum
 }
 
 // NewTabFile creates a new TabFile instance.
@@ -50,62 +57,56 @@ func (f *TabFile) GetCols() int {
 
 // Load is
 func (f *TabFile) Load(path string) bool {
+	return f.LoadWithError(path) == nil
+}
+
+// LoadWithError is like Load, but returns why it failed. If a column bound by BindEnum has unknown names, the error
+// lists them and the table is still loaded, so that callers can report or fix the bad rows.
+func (f *TabFile) LoadWithError(path string) error {
 	var fi *os.File
 	var err error
 	var buff []byte
-	var size int
 
 	if fi, err = os.Open(path); err != nil {
-		return false
+		return err
 	}
 	defer fi.Close()
 
 	if buff, err = ioutil.ReadAll(fi); err != nil {
-		return false
+		return err
 	}
 
-	size = len(buff)
-	if size > 0 {
-		f.createTabOffsets(buff, size)
-	}
-
-	return true
+	return f.loadBytes(buff)
 }
 
 // Save is
 func (f *TabFile) Save(path string) bool {
 	var fi *os.File
 	var err error
-	var buff bytes.Buffer
-
-	for i := 0; i < f.rows; i++ {
-		for j := 0; j < f.cols; j++ {
-			idx := i*f.cols + j
-			if j < f.cols-1 {
-				buff.WriteString(fmt.Sprintf("%s\t", f.tabs[idx].content))
-			} else {
-				buff.WriteString(fmt.Sprintf("%s\r\n", f.tabs[idx].content))
-			}
-		}
-	}
 
 	if fi, err = os.Create(path); err != nil {
 		return false
 	}
 	defer fi.Close()
 
-	if _, err = fi.WriteString(buff.String()); err != nil {
+	if _, err = fi.Write(f.encode()); err != nil {
 		return false
 	}
 
 	return true
 }
 
+// SaveAtomic is like Save, but replaces the file atomically and keeps 'backups' old versions, see WriteFileAtomic.
+func (f *TabFile) SaveAtomic(path string, backups int) bool {
+	return WriteFileAtomic(path, f.encode(), backups) == nil
+}
+
 // Reset is
 func (f *TabFile) Reset() {
 	f.rows = 0
 	f.cols = 0
 	f.tabs = nil
+	f.clearColumnCache()
 }
 
 // PrintTabInfo is
@@ -491,9 +492,37 @@ func (f *TabFile) SetCell(row int, col int, val string) bool {
 
 	idx := row*f.cols + col
 	f.tabs[idx].content = val
+	f.invalidateColumnCache(col)
 	return true
 }
 
+func (f *TabFile) loadBytes(buff []byte) error {
+	f.clearColumnCache()
+
+	if size := len(buff); size > 0 {
+		f.createTabOffsets(buff, size)
+	}
+
+	return f.checkEnumBinds()
+}
+
+func (f *TabFile) encode() []byte {
+	var buff bytes.Buffer
+
+	for i := 0; i < f.rows; i++ {
+		for j := 0; j < f.cols; j++ {
+			idx := i*f.cols + j
+			if j < f.cols-1 {
+				buff.WriteString(fmt.Sprintf("%s\t", f.tabs[idx].content))
+			} else {
+				buff.WriteString(fmt.Sprintf("%s\r\n", f.tabs[idx].content))
+			}
+		}
+	}
+
+	return buff.Bytes()
+}
+
 func (f *TabFile) createTabOffsets(buff []byte, size int) {
 	//defer TimeCostStatistics(time.Now(), "TabFile.createTabOffsets")
 	f.getRowsAndColumns(buff, size)
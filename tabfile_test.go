@@ -1,6 +1,101 @@
 package goblazer
 
-import "testing"
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func newTestTabFile(t *testing.T, content string) *TabFile {
+	fi, err := ioutil.TempFile("", "tabfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(fi.Name())
+
+	fi.WriteString(content)
+	fi.Close()
+
+	f := NewTabFile()
+	if !f.Load(fi.Name()) {
+		t.Fatal("load tab file failed")
+	}
+	return f
+}
+
+func Test_TabFileFreeze(t *testing.T) {
+	f := newTestTabFile(t, "Name\tHP\r\nwolf\t100\r\nbear\t250\r\n")
+	s := f.Freeze()
+
+	f.SetIntByStrIdx("wolf", "HP", 1)
+
+	if s.GetIntByStrIdx("wolf", "HP", 0) == 100 && f.GetIntByStrIdx("wolf", "HP", 0) == 1 {
+		t.Log("Test_TabFileFreeze succeeded")
+	} else {
+		t.Error("Test_TabFileFreeze failed")
+	}
+}
+
+func Test_TabFileInt32Column(t *testing.T) {
+	f := newTestTabFile(t, "Name\tHP\r\nwolf\t100\r\nbear\t250\r\nfox\t100\r\n")
+
+	c := f.Int32Column(f.FindCol("HP"))
+	if c.Min() != 100 || c.Max() != 250 || c.Sum() != 450 || c.Histogram()[100] != 2 {
+		t.Error("Test_TabFileInt32Column failed")
+		return
+	}
+
+	f.SetIntByStrIdx("fox", "HP", 50)
+	done := make(chan TabInt32Column)
+	go func() { done <- f.Int32Column(f.FindCol("HP")) }()
+	c = f.Int32Column(f.FindCol("HP"))
+	if c2 := <-done; c2.Sum() != 400 {
+		t.Error("Test_TabFileInt32Column failed")
+		return
+	}
+
+	g := newTestTabFile(t, "Name\tRate\r\na\t1\r\nb\tinf\r\nc\t3\r\n")
+	if h := g.Float64Column(1).Histogram(2); h[0] != 1 || h[1] != 1 {
+		t.Error("Test_TabFileInt32Column failed")
+		return
+	}
+
+	if c.Min() == 50 && c.Sum() == 400 {
+		t.Log("Test_TabFileInt32Column succeeded")
+	} else {
+		t.Error("Test_TabFileInt32Column failed")
+	}
+}
+
+func Test_TabFileEnum(t *testing.T) {
+	RegisterTabEnum(NewTabEnum("Quality", map[string]int{"Common": 1, "Rare": 2, "Epic": 3}))
+
+	f := newTestTabFile(t, "Name\tQuality\r\nsword\tEpic\r\nshield\tLegend\r\n")
+	if err := f.BindEnum("Quality", "Quality"); err == nil {
+		t.Error("Test_TabFileEnum failed")
+		return
+	}
+
+	f.SetEnumByStrIdx("shield", "Quality", "Quality", 2)
+	if f.BindEnum("Quality", "Quality") != nil || f.GetEnumByMixIdx(1, "Quality", "Quality", 0) != 3 ||
+		f.GetStrByStrIdx("shield", "Quality", "") != "Rare" {
+		t.Error("Test_TabFileEnum failed")
+		return
+	}
+
+	fi, _ := ioutil.TempFile("", "tabfile")
+	fi.WriteString("Name\tQuality\r\nsword\tEpic\r\nshield\tLegend\r\n")
+	fi.Close()
+	defer os.Remove(fi.Name())
+
+	err := f.LoadWithError(fi.Name())
+	if err != nil && strings.Contains(err.Error(), "row 2: Legend") && f.GetRows() == 3 {
+		t.Log("Test_TabFileEnum succeeded")
+	} else {
+		t.Error("Test_TabFileEnum failed")
+	}
+}
 
 func Benchmark_TabFileLoad_UTF8(b *testing.B) {
 	b.StopTimer()
@@ -0,0 +1,187 @@
+package goblazer
+
+// TabSnapshot is an immutable copy of a TabFile. All methods of TabSnapshot only read, so one snapshot can be
+// shared by any number of goroutines without locking. Later changes of the original TabFile are not visible.
+type TabSnapshot struct {
+	t *TabFile
+}
+
+// Freeze copies the current content of tab file into a new TabSnapshot.
+func (f *TabFile) Freeze() *TabSnapshot {
+	t := NewTabFile()
+	t.rows = f.rows
+	t.cols = f.cols
+	t.tabs = make([]*tabCell, len(f.tabs))
+	for i, c := range f.tabs {
+		t.tabs[i] = newTabCell(c.content)
+	}
+
+	s := new(TabSnapshot)
+	s.t = t
+	return s
+}
+
+// Save writes the snapshot to 'path' in the same format as TabFile.Save.
+func (s *TabSnapshot) Save(path string) bool {
+	return s.t.Save(path)
+}
+
+// GetRows returns the number of rows of snapshot
+func (s *TabSnapshot) GetRows() int {
+	return s.t.GetRows()
+}
+
+// GetCols returns the number of columns of snapshot
+func (s *TabSnapshot) GetCols() int {
+	return s.t.GetCols()
+}
+
+// GetIntByIntIdx is the same as TabFile.GetIntByIntIdx
+func (s *TabSnapshot) GetIntByIntIdx(row int, col int, dflt int) int {
+	return s.t.GetIntByIntIdx(row, col, dflt)
+}
+
+// GetIntByStrIdx is the same as TabFile.GetIntByStrIdx
+func (s *TabSnapshot) GetIntByStrIdx(row string, col string, dflt int) int {
+	return s.t.GetIntByStrIdx(row, col, dflt)
+}
+
+// GetIntByMixIdx is the same as TabFile.GetIntByMixIdx
+func (s *TabSnapshot) GetIntByMixIdx(row int, col string, dflt int) int {
+	return s.t.GetIntByMixIdx(row, col, dflt)
+}
+
+// GetByteByIntIdx is the same as TabFile.GetByteByIntIdx
+func (s *TabSnapshot) GetByteByIntIdx(row int, col int, dflt byte) byte {
+	return s.t.GetByteByIntIdx(row, col, dflt)
+}
+
+// GetByteByStrIdx is the same as TabFile.GetByteByStrIdx
+func (s *TabSnapshot) GetByteByStrIdx(row string, col string, dflt byte) byte {
+	return s.t.GetByteByStrIdx(row, col, dflt)
+}
+
+// GetByteByMixIdx is the same as TabFile.GetByteByMixIdx
+func (s *TabSnapshot) GetByteByMixIdx(row int, col string, dflt byte) byte {
+	return s.t.GetByteByMixIdx(row, col, dflt)
+}
+
+// GetInt8ByIntIdx is the same as TabFile.GetInt8ByIntIdx
+func (s *TabSnapshot) GetInt8ByIntIdx(row int, col int, dflt int8) int8 {
+	return s.t.GetInt8ByIntIdx(row, col, dflt)
+}
+
+// GetInt8ByStrIdx is the same as TabFile.GetInt8ByStrIdx
+func (s *TabSnapshot) GetInt8ByStrIdx(row string, col string, dflt int8) int8 {
+	return s.t.GetInt8ByStrIdx(row, col, dflt)
+}
+
+// GetInt8ByMixIdx is the same as TabFile.GetInt8ByMixIdx
+func (s *TabSnapshot) GetInt8ByMixIdx(row int, col string, dflt int8) int8 {
+	return s.t.GetInt8ByMixIdx(row, col, dflt)
+}
+
+// GetInt16ByIntIdx is the same as TabFile.GetInt16ByIntIdx
+func (s *TabSnapshot) GetInt16ByIntIdx(row int, col int, dflt int16) int16 {
+	return s.t.GetInt16ByIntIdx(row, col, dflt)
+}
+
+// GetInt16ByStrIdx is the same as TabFile.GetInt16ByStrIdx
+func (s *TabSnapshot) GetInt16ByStrIdx(row string, col string, dflt int16) int16 {
+	return s.t.GetInt16ByStrIdx(row, col, dflt)
+}
+
+// GetInt16ByMixIdx is the same as TabFile.GetInt16ByMixIdx
+func (s *TabSnapshot) GetInt16ByMixIdx(row int, col string, dflt int16) int16 {
+	return s.t.GetInt16ByMixIdx(row, col, dflt)
+}
+
+// GetInt32ByIntIdx is the same as TabFile.GetInt32ByIntIdx
+func (s *TabSnapshot) GetInt32ByIntIdx(row int, col int, dflt int32) int32 {
+	return s.t.GetInt32ByIntIdx(row, col, dflt)
+}
+
+// GetInt32ByStrIdx is the same as TabFile.GetInt32ByStrIdx
+func (s *TabSnapshot) GetInt32ByStrIdx(row string, col string, dflt int32) int32 {
+	return s.t.GetInt32ByStrIdx(row, col, dflt)
+}
+
+// GetInt32ByMixIdx is the same as TabFile.GetInt32ByMixIdx
+func (s *TabSnapshot) GetInt32ByMixIdx(row int, col string, dflt int32) int32 {
+	return s.t.GetInt32ByMixIdx(row, col, dflt)
+}
+
+// GetInt64ByIntIdx is the same as TabFile.GetInt64ByIntIdx
+func (s *TabSnapshot) GetInt64ByIntIdx(row int, col int, dflt int64) int64 {
+	return s.t.GetInt64ByIntIdx(row, col, dflt)
+}
+
+// GetInt64ByStrIdx is the same as TabFile.GetInt64ByStrIdx
+func (s *TabSnapshot) GetInt64ByStrIdx(row string, col string, dflt int64) int64 {
+	return s.t.GetInt64ByStrIdx(row, col, dflt)
+}
+
+// GetInt64ByMixIdx is the same as TabFile.GetInt64ByMixIdx
+func (s *TabSnapshot) GetInt64ByMixIdx(row int, col string, dflt int64) int64 {
+	return s.t.GetInt64ByMixIdx(row, col, dflt)
+}
+
+// GetStrByIntIdx is the same as TabFile.GetStrByIntIdx
+func (s *TabSnapshot) GetStrByIntIdx(row int, col int, dflt string) string {
+	return s.t.GetStrByIntIdx(row, col, dflt)
+}
+
+// GetStrByStrIdx is the same as TabFile.GetStrByStrIdx
+func (s *TabSnapshot) GetStrByStrIdx(row string, col string, dflt string) string {
+	return s.t.GetStrByStrIdx(row, col, dflt)
+}
+
+// GetStrByMixIdx is the same as TabFile.GetStrByMixIdx
+func (s *TabSnapshot) GetStrByMixIdx(row int, col string, dflt string) string {
+	return s.t.GetStrByMixIdx(row, col, dflt)
+}
+
+// GetFloat32ByIntIdx is the same as TabFile.GetFloat32ByIntIdx
+func (s *TabSnapshot) GetFloat32ByIntIdx(row int, col int, dflt float32) float32 {
+	return s.t.GetFloat32ByIntIdx(row, col, dflt)
+}
+
+// GetFloat32ByStrIdx is the same as TabFile.GetFloat32ByStrIdx
+func (s *TabSnapshot) GetFloat32ByStrIdx(row string, col string, dflt float32) float32 {
+	return s.t.GetFloat32ByStrIdx(row, col, dflt)
+}
+
+// GetFloat32ByMixIdx is the same as TabFile.GetFloat32ByMixIdx
+func (s *TabSnapshot) GetFloat32ByMixIdx(row int, col string, dflt float32) float32 {
+	return s.t.GetFloat32ByMixIdx(row, col, dflt)
+}
+
+// GetFloat64ByIntIdx is the same as TabFile.GetFloat64ByIntIdx
+func (s *TabSnapshot) GetFloat64ByIntIdx(row int, col int, dflt float64) float64 {
+	return s.t.GetFloat64ByIntIdx(row, col, dflt)
+}
+
+// GetFloat64ByStrIdx is the same as TabFile.GetFloat64ByStrIdx
+func (s *TabSnapshot) GetFloat64ByStrIdx(row string, col string, dflt float64) float64 {
+	return s.t.GetFloat64ByStrIdx(row, col, dflt)
+}
+
+// GetFloat64ByMixIdx is the same as TabFile.GetFloat64ByMixIdx
+func (s *TabSnapshot) GetFloat64ByMixIdx(row int, col string, dflt float64) float64 {
+	return s.t.GetFloat64ByMixIdx(row, col, dflt)
+}
+
+// FindCol is the same as TabFile.FindCol
+func (s *TabSnapshot) FindCol(col string) int {
+	return s.t.FindCol(col)
+}
+
+// FindRow is the same as TabFile.FindRow
+func (s *TabSnapshot) FindRow(row string) int {
+	return s.t.FindRow(row)
+}
+
+// GetCell is the same as TabFile.GetCell
+func (s *TabSnapshot) GetCell(row int, col int) (string, bool) {
+	return s.t.GetCell(row, col)
+}